@@ -0,0 +1,25 @@
+package sqlserver
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StoredAs returns the type of a computed column that is physically stored in the table, it's required to index a
+// computed column with an imprecise expression. Use it as the type of a column, the expression is in Transact-SQL:
+//
+//	table.Column("total", sqlserver.StoredAs("[price] * [quantity]"))
+func StoredAs(expression string) string {
+	return VirtualAs(expression) + " persisted"
+}
+
+// VirtualAs returns the type of a computed column that is calculated when it's queried, the default value and the
+// nullable and identity modifiers don't apply to it. The Extra of the column returned by GetColumns has the same
+// format, so it can be used to create the column again.
+func VirtualAs(expression string) string {
+	return fmt.Sprintf("as (%s)", expression)
+}
+
+func isComputed(columnType string) bool {
+	return strings.HasPrefix(columnType, "as (")
+}
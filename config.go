@@ -2,8 +2,12 @@ package sqlserver
 
 import (
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/goravel/framework/contracts/config"
+	"github.com/spf13/cast"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/goravel/sqlserver/contracts"
 )
@@ -67,6 +71,17 @@ func (r *Config) fillDefault(configs []contracts.Config) []contracts.FullConfig
 				fullConfig.NameReplacer = replacer
 			}
 		}
+		fullConfig.ParameterSizing = r.config.GetString(fmt.Sprintf("database.connections.%s.parameter_sizing", r.connection))
+		fullConfig.VarcharParameters = r.config.GetBool(fmt.Sprintf("database.connections.%s.varchar_parameters", r.connection))
+		fullConfig.Unicode = r.config.GetString(fmt.Sprintf("database.connections.%s.unicode", r.connection))
+		// The error of an invalid docker config is returned by Docker of the driver.
+		fullConfig.Docker, _ = r.dockerConfig()
+		fullConfig.Metrics = r.config.GetBool(fmt.Sprintf("database.connections.%s.metrics", r.connection))
+		if fullConfig.Tracing = r.config.GetBool(fmt.Sprintf("database.connections.%s.tracing", r.connection)); fullConfig.Tracing {
+			if tracerProvider, ok := r.config.Get(fmt.Sprintf("database.connections.%s.tracer_provider", r.connection)).(trace.TracerProvider); ok {
+				fullConfig.TracerProvider = tracerProvider
+			}
+		}
 
 		// If read or write is empty, use the default config
 		if fullConfig.Dsn == "" {
@@ -102,3 +117,145 @@ func (r *Config) fillDefault(configs []contracts.Config) []contracts.FullConfig
 
 	return fullConfigs
 }
+
+// StaticConfig is a ConfigBuilder built from FullConfigs directly, it's used to construct the driver
+// without a goravel Application, see NewStandaloneSqlserver.
+type StaticConfig struct {
+	connection string
+	readers    []contracts.FullConfig
+	writers    []contracts.FullConfig
+}
+
+// dockerConfig reads the docker block of the connection, it can be a contracts.DockerConfig or a map.
+func (r *Config) dockerConfig() (contracts.DockerConfig, error) {
+	switch dockerConfig := r.config.Get(fmt.Sprintf("database.connections.%s.docker", r.connection)).(type) {
+	case contracts.DockerConfig:
+		return dockerConfig, nil
+	case map[string]any:
+		users, err := dockerUsers(dockerConfig["users"])
+
+		return contracts.DockerConfig{
+			Image:       cast.ToString(dockerConfig["image"]),
+			Tag:         cast.ToString(dockerConfig["tag"]),
+			Edition:     cast.ToString(dockerConfig["edition"]),
+			Collation:   cast.ToString(dockerConfig["collation"]),
+			Agent:       cast.ToBool(dockerConfig["agent"]),
+			MemoryLimit: cast.ToInt(dockerConfig["memory_limit"]),
+			FullText:    cast.ToBool(dockerConfig["full_text"]),
+			Contained:   cast.ToBool(dockerConfig["contained"]),
+			Roles:       cast.ToStringSlice(dockerConfig["roles"]),
+			Schema:      cast.ToString(dockerConfig["schema"]),
+			Users:       users,
+			// ready_timeout is in seconds
+			ReadyTimeout: time.Duration(cast.ToInt(dockerConfig["ready_timeout"])) * time.Second,
+		}, err
+	default:
+		return contracts.DockerConfig{}, nil
+	}
+}
+
+// dockerUsers reads the users of the docker block, a list of contracts.DockerUser or of maps.
+func dockerUsers(value any) ([]contracts.DockerUser, error) {
+	var items []any
+	switch users := value.(type) {
+	case nil:
+		return nil, nil
+	case []contracts.DockerUser:
+		return users, nil
+	case []map[string]any:
+		for _, user := range users {
+			items = append(items, user)
+		}
+	case []any:
+		items = users
+	default:
+		return nil, fmt.Errorf("invalid users %T of the Sqlserver docker config, they should be a list of maps", value)
+	}
+
+	var users []contracts.DockerUser
+	for i, item := range items {
+		switch user := item.(type) {
+		case contracts.DockerUser:
+			users = append(users, user)
+		case map[string]any:
+			users = append(users, contracts.DockerUser{
+				Username:  cast.ToString(user["username"]),
+				Password:  cast.ToString(user["password"]),
+				Contained: cast.ToBool(user["contained"]),
+				Roles:     cast.ToStringSlice(user["roles"]),
+				Schema:    cast.ToString(user["schema"]),
+			})
+		default:
+			return nil, fmt.Errorf("invalid user %d %T of the Sqlserver docker config, it should be a map", i, item)
+		}
+	}
+
+	return users, nil
+}
+
+func NewStaticConfig(writers, readers []contracts.FullConfig) *StaticConfig {
+	var connection string
+	if len(writers) > 0 {
+		connection = writers[0].Connection
+	}
+	if connection == "" {
+		connection = "sqlserver"
+	}
+
+	return &StaticConfig{
+		connection: connection,
+		readers:    fillStaticDefault(readers, connection),
+		writers:    fillStaticDefault(writers, connection),
+	}
+}
+
+// Config returns nil, there is no goravel config behind a StaticConfig.
+func (r *StaticConfig) Config() config.Config {
+	return nil
+}
+
+func (r *StaticConfig) Connection() string {
+	return r.connection
+}
+
+func (r *StaticConfig) Readers() []contracts.FullConfig {
+	return slices.Clone(r.readers)
+}
+
+func (r *StaticConfig) Writers() []contracts.FullConfig {
+	return slices.Clone(r.writers)
+}
+
+func (r *StaticConfig) setPort(port int) {
+	if len(r.writers) > 0 {
+		r.writers[0].Port = port
+	}
+}
+
+func (r *StaticConfig) setReaderPorts(ports []int) {
+	for i := range r.readers {
+		if i < len(ports) && ports[i] >= 0 {
+			r.readers[i].Port = ports[i]
+		}
+	}
+}
+
+func fillStaticDefault(fullConfigs []contracts.FullConfig, connection string) []contracts.FullConfig {
+	if len(fullConfigs) == 0 {
+		return nil
+	}
+
+	filled := make([]contracts.FullConfig, len(fullConfigs))
+	for i, fullConfig := range fullConfigs {
+		if fullConfig.Connection == "" {
+			fullConfig.Connection = connection
+		}
+		if fullConfig.Timezone == "" {
+			fullConfig.Timezone = "UTC"
+		}
+		fullConfig.Driver = Name
+		filled[i] = fullConfig
+	}
+
+	return filled
+}
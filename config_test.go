@@ -5,7 +5,9 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel/trace/noop"
 
 	mocksconfig "github.com/goravel/framework/mocks/config"
 
@@ -50,6 +52,12 @@ func (s *ConfigTestSuite) TestReads() {
 	s.mockConfig.EXPECT().GetBool(fmt.Sprintf("database.connections.%s.singular", s.connection)).Return(false).Once()
 	s.mockConfig.EXPECT().GetBool(fmt.Sprintf("database.connections.%s.no_lower_case", s.connection)).Return(false).Once()
 	s.mockConfig.EXPECT().Get(fmt.Sprintf("database.connections.%s.name_replacer", s.connection)).Return(nil).Once()
+	s.mockConfig.EXPECT().GetString(fmt.Sprintf("database.connections.%s.parameter_sizing", s.connection)).Return("").Once()
+	s.mockConfig.EXPECT().GetBool(fmt.Sprintf("database.connections.%s.varchar_parameters", s.connection)).Return(false).Once()
+	s.mockConfig.EXPECT().GetString(fmt.Sprintf("database.connections.%s.unicode", s.connection)).Return("").Once()
+	s.mockConfig.EXPECT().Get(fmt.Sprintf("database.connections.%s.docker", s.connection)).Return(nil).Once()
+	s.mockConfig.EXPECT().GetBool(fmt.Sprintf("database.connections.%s.metrics", s.connection)).Return(false).Once()
+	s.mockConfig.EXPECT().GetBool(fmt.Sprintf("database.connections.%s.tracing", s.connection)).Return(false).Once()
 	s.mockConfig.EXPECT().GetString(fmt.Sprintf("database.connections.%s.charset", s.connection)).Return("utf8mb4").Once()
 	s.mockConfig.EXPECT().GetString(fmt.Sprintf("database.connections.%s.timezone", s.connection)).Return("UTC").Once()
 	s.Equal([]contracts.FullConfig{
@@ -81,6 +89,12 @@ func (s *ConfigTestSuite) TestWrites() {
 		s.mockConfig.EXPECT().GetBool(fmt.Sprintf("database.connections.%s.singular", s.connection)).Return(false).Once()
 		s.mockConfig.EXPECT().GetBool(fmt.Sprintf("database.connections.%s.no_lower_case", s.connection)).Return(false).Once()
 		s.mockConfig.EXPECT().Get(fmt.Sprintf("database.connections.%s.name_replacer", s.connection)).Return(nil).Once()
+		s.mockConfig.EXPECT().GetString(fmt.Sprintf("database.connections.%s.parameter_sizing", s.connection)).Return("").Once()
+		s.mockConfig.EXPECT().GetBool(fmt.Sprintf("database.connections.%s.varchar_parameters", s.connection)).Return(false).Once()
+		s.mockConfig.EXPECT().GetString(fmt.Sprintf("database.connections.%s.unicode", s.connection)).Return("").Once()
+		s.mockConfig.EXPECT().Get(fmt.Sprintf("database.connections.%s.docker", s.connection)).Return(nil).Once()
+		s.mockConfig.EXPECT().GetBool(fmt.Sprintf("database.connections.%s.metrics", s.connection)).Return(false).Once()
+		s.mockConfig.EXPECT().GetBool(fmt.Sprintf("database.connections.%s.tracing", s.connection)).Return(false).Once()
 		s.mockConfig.EXPECT().GetString(fmt.Sprintf("database.connections.%s.charset", s.connection)).Return("utf8mb4").Once()
 		s.mockConfig.EXPECT().GetString(fmt.Sprintf("database.connections.%s.dsn", s.connection)).Return("dsn").Once()
 		s.mockConfig.EXPECT().GetString(fmt.Sprintf("database.connections.%s.host", s.connection)).Return("localhost").Once()
@@ -122,6 +136,12 @@ func (s *ConfigTestSuite) TestWrites() {
 		s.mockConfig.EXPECT().GetBool(fmt.Sprintf("database.connections.%s.singular", s.connection)).Return(false).Once()
 		s.mockConfig.EXPECT().GetBool(fmt.Sprintf("database.connections.%s.no_lower_case", s.connection)).Return(false).Once()
 		s.mockConfig.EXPECT().Get(fmt.Sprintf("database.connections.%s.name_replacer", s.connection)).Return(nil).Once()
+		s.mockConfig.EXPECT().GetString(fmt.Sprintf("database.connections.%s.parameter_sizing", s.connection)).Return("").Once()
+		s.mockConfig.EXPECT().GetBool(fmt.Sprintf("database.connections.%s.varchar_parameters", s.connection)).Return(false).Once()
+		s.mockConfig.EXPECT().GetString(fmt.Sprintf("database.connections.%s.unicode", s.connection)).Return("").Once()
+		s.mockConfig.EXPECT().Get(fmt.Sprintf("database.connections.%s.docker", s.connection)).Return(nil).Once()
+		s.mockConfig.EXPECT().GetBool(fmt.Sprintf("database.connections.%s.metrics", s.connection)).Return(false).Once()
+		s.mockConfig.EXPECT().GetBool(fmt.Sprintf("database.connections.%s.tracing", s.connection)).Return(false).Once()
 		s.mockConfig.EXPECT().GetString(fmt.Sprintf("database.connections.%s.charset", s.connection)).Return("utf8mb4").Once()
 		s.mockConfig.EXPECT().GetString(fmt.Sprintf("database.connections.%s.dsn", s.connection)).Return("dsn").Once()
 		s.mockConfig.EXPECT().GetString(fmt.Sprintf("database.connections.%s.host", s.connection)).Return("localhost").Once()
@@ -166,6 +186,7 @@ func (s *ConfigTestSuite) TestFillDefault() {
 	charset := "utf8mb4"
 	timezone := "UTC"
 	nameReplacer := strings.NewReplacer("a", "b")
+	tracerProvider := noop.NewTracerProvider()
 
 	tests := []struct {
 		name          string
@@ -186,6 +207,12 @@ func (s *ConfigTestSuite) TestFillDefault() {
 				s.mockConfig.EXPECT().GetBool(fmt.Sprintf("database.connections.%s.singular", s.connection)).Return(singular).Once()
 				s.mockConfig.EXPECT().GetBool(fmt.Sprintf("database.connections.%s.no_lower_case", s.connection)).Return(true).Once()
 				s.mockConfig.EXPECT().Get(fmt.Sprintf("database.connections.%s.name_replacer", s.connection)).Return(nameReplacer).Once()
+				s.mockConfig.EXPECT().GetString(fmt.Sprintf("database.connections.%s.parameter_sizing", s.connection)).Return("").Once()
+				s.mockConfig.EXPECT().GetBool(fmt.Sprintf("database.connections.%s.varchar_parameters", s.connection)).Return(false).Once()
+				s.mockConfig.EXPECT().GetString(fmt.Sprintf("database.connections.%s.unicode", s.connection)).Return("").Once()
+				s.mockConfig.EXPECT().Get(fmt.Sprintf("database.connections.%s.docker", s.connection)).Return(nil).Once()
+				s.mockConfig.EXPECT().GetBool(fmt.Sprintf("database.connections.%s.metrics", s.connection)).Return(false).Once()
+				s.mockConfig.EXPECT().GetBool(fmt.Sprintf("database.connections.%s.tracing", s.connection)).Return(false).Once()
 				s.mockConfig.EXPECT().GetString(fmt.Sprintf("database.connections.%s.dsn", s.connection)).Return(dsn).Once()
 				s.mockConfig.EXPECT().GetString(fmt.Sprintf("database.connections.%s.host", s.connection)).Return(host).Once()
 				s.mockConfig.EXPECT().GetInt(fmt.Sprintf("database.connections.%s.port", s.connection)).Return(port).Once()
@@ -233,6 +260,12 @@ func (s *ConfigTestSuite) TestFillDefault() {
 				s.mockConfig.EXPECT().GetBool(fmt.Sprintf("database.connections.%s.singular", s.connection)).Return(singular).Once()
 				s.mockConfig.EXPECT().GetBool(fmt.Sprintf("database.connections.%s.no_lower_case", s.connection)).Return(true).Once()
 				s.mockConfig.EXPECT().Get(fmt.Sprintf("database.connections.%s.name_replacer", s.connection)).Return(nameReplacer).Once()
+				s.mockConfig.EXPECT().GetString(fmt.Sprintf("database.connections.%s.parameter_sizing", s.connection)).Return("").Once()
+				s.mockConfig.EXPECT().GetBool(fmt.Sprintf("database.connections.%s.varchar_parameters", s.connection)).Return(false).Once()
+				s.mockConfig.EXPECT().GetString(fmt.Sprintf("database.connections.%s.unicode", s.connection)).Return("").Once()
+				s.mockConfig.EXPECT().Get(fmt.Sprintf("database.connections.%s.docker", s.connection)).Return(nil).Once()
+				s.mockConfig.EXPECT().GetBool(fmt.Sprintf("database.connections.%s.metrics", s.connection)).Return(false).Once()
+				s.mockConfig.EXPECT().GetBool(fmt.Sprintf("database.connections.%s.tracing", s.connection)).Return(false).Once()
 				s.mockConfig.EXPECT().GetString(fmt.Sprintf("database.connections.%s.charset", s.connection)).Return(charset).Once()
 				s.mockConfig.EXPECT().GetString(fmt.Sprintf("database.connections.%s.timezone", s.connection)).Return(timezone).Once()
 			},
@@ -257,6 +290,121 @@ func (s *ConfigTestSuite) TestFillDefault() {
 				},
 			},
 		},
+		{
+			name: "success when tracing is enabled",
+			configs: []contracts.Config{
+				{
+					Dsn:      dsn,
+					Host:     host,
+					Port:     port,
+					Database: database,
+					Username: username,
+					Password: password,
+				},
+			},
+			setup: func() {
+				s.mockConfig.EXPECT().GetString(fmt.Sprintf("database.connections.%s.prefix", s.connection)).Return(prefix).Once()
+				s.mockConfig.EXPECT().GetBool(fmt.Sprintf("database.connections.%s.singular", s.connection)).Return(singular).Once()
+				s.mockConfig.EXPECT().GetBool(fmt.Sprintf("database.connections.%s.no_lower_case", s.connection)).Return(false).Once()
+				s.mockConfig.EXPECT().Get(fmt.Sprintf("database.connections.%s.name_replacer", s.connection)).Return(nil).Once()
+				s.mockConfig.EXPECT().GetString(fmt.Sprintf("database.connections.%s.parameter_sizing", s.connection)).Return("").Once()
+				s.mockConfig.EXPECT().GetBool(fmt.Sprintf("database.connections.%s.varchar_parameters", s.connection)).Return(false).Once()
+				s.mockConfig.EXPECT().GetString(fmt.Sprintf("database.connections.%s.unicode", s.connection)).Return("").Once()
+				s.mockConfig.EXPECT().Get(fmt.Sprintf("database.connections.%s.docker", s.connection)).Return(nil).Once()
+				s.mockConfig.EXPECT().GetBool(fmt.Sprintf("database.connections.%s.metrics", s.connection)).Return(true).Once()
+				s.mockConfig.EXPECT().GetBool(fmt.Sprintf("database.connections.%s.tracing", s.connection)).Return(true).Once()
+				s.mockConfig.EXPECT().Get(fmt.Sprintf("database.connections.%s.tracer_provider", s.connection)).Return(tracerProvider).Once()
+				s.mockConfig.EXPECT().GetString(fmt.Sprintf("database.connections.%s.charset", s.connection)).Return(charset).Once()
+				s.mockConfig.EXPECT().GetString(fmt.Sprintf("database.connections.%s.timezone", s.connection)).Return(timezone).Once()
+			},
+			expectConfigs: []contracts.FullConfig{
+				{
+					Connection: s.connection,
+					Driver:     Name,
+					Prefix:     prefix,
+					Singular:   singular,
+					Charset:    charset,
+					Config: contracts.Config{
+						Dsn:      dsn,
+						Database: database,
+						Host:     host,
+						Port:     port,
+						Username: username,
+						Password: password,
+					},
+					Timezone:       timezone,
+					Metrics:        true,
+					Tracing:        true,
+					TracerProvider: tracerProvider,
+				},
+			},
+		},
+		{
+			name: "success when docker is configured",
+			configs: []contracts.Config{
+				{
+					Dsn:      dsn,
+					Host:     host,
+					Port:     port,
+					Database: database,
+					Username: username,
+					Password: password,
+				},
+			},
+			setup: func() {
+				s.mockConfig.EXPECT().GetString(fmt.Sprintf("database.connections.%s.prefix", s.connection)).Return(prefix).Once()
+				s.mockConfig.EXPECT().GetBool(fmt.Sprintf("database.connections.%s.singular", s.connection)).Return(singular).Once()
+				s.mockConfig.EXPECT().GetBool(fmt.Sprintf("database.connections.%s.no_lower_case", s.connection)).Return(false).Once()
+				s.mockConfig.EXPECT().Get(fmt.Sprintf("database.connections.%s.name_replacer", s.connection)).Return(nil).Once()
+				s.mockConfig.EXPECT().GetString(fmt.Sprintf("database.connections.%s.parameter_sizing", s.connection)).Return("").Once()
+				s.mockConfig.EXPECT().GetBool(fmt.Sprintf("database.connections.%s.varchar_parameters", s.connection)).Return(false).Once()
+				s.mockConfig.EXPECT().GetString(fmt.Sprintf("database.connections.%s.unicode", s.connection)).Return("").Once()
+				s.mockConfig.EXPECT().Get(fmt.Sprintf("database.connections.%s.docker", s.connection)).Return(map[string]any{
+					"tag":          "2019-latest",
+					"edition":      "Express",
+					"collation":    "Latin1_General_100_CI_AS_SC_UTF8",
+					"agent":        true,
+					"memory_limit": 2048,
+					"roles":        []string{"db_datareader"},
+					"users": []map[string]any{
+						{"username": "reader", "password": "Reader!123", "roles": []string{"db_datareader"}, "schema": "reports"},
+					},
+				}).Once()
+				s.mockConfig.EXPECT().GetBool(fmt.Sprintf("database.connections.%s.metrics", s.connection)).Return(false).Once()
+				s.mockConfig.EXPECT().GetBool(fmt.Sprintf("database.connections.%s.tracing", s.connection)).Return(false).Once()
+				s.mockConfig.EXPECT().GetString(fmt.Sprintf("database.connections.%s.charset", s.connection)).Return(charset).Once()
+				s.mockConfig.EXPECT().GetString(fmt.Sprintf("database.connections.%s.timezone", s.connection)).Return(timezone).Once()
+			},
+			expectConfigs: []contracts.FullConfig{
+				{
+					Connection: s.connection,
+					Driver:     Name,
+					Prefix:     prefix,
+					Singular:   singular,
+					Charset:    charset,
+					Config: contracts.Config{
+						Dsn:      dsn,
+						Database: database,
+						Host:     host,
+						Port:     port,
+						Username: username,
+						Password: password,
+					},
+					Docker: contracts.DockerConfig{
+						Tag:         "2019-latest",
+						Edition:     "Express",
+						Collation:   "Latin1_General_100_CI_AS_SC_UTF8",
+						Agent:       true,
+						MemoryLimit: 2048,
+						Roles:       []string{"db_datareader"},
+						Users: []contracts.DockerUser{
+							{Username: "reader", Password: "Reader!123", Roles: []string{"db_datareader"}, Schema: "reports"},
+						},
+					},
+					Timezone: timezone,
+				},
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -268,3 +416,83 @@ func (s *ConfigTestSuite) TestFillDefault() {
 		})
 	}
 }
+
+func (s *ConfigTestSuite) TestDockerConfig() {
+	tests := []struct {
+		name         string
+		users        any
+		expectUsers  []contracts.DockerUser
+		expectErrStr string
+	}{
+		{
+			name: "users of maps",
+			users: []map[string]any{
+				{"username": "reader", "password": "Reader!123", "roles": []string{"db_datareader"}},
+			},
+			expectUsers: []contracts.DockerUser{{Username: "reader", Password: "Reader!123", Roles: []string{"db_datareader"}}},
+		},
+		{
+			name: "users of any",
+			users: []any{
+				map[string]any{"username": "reader", "password": "Reader!123", "contained": true, "schema": "reports"},
+				contracts.DockerUser{Username: "writer", Password: "Writer!123"},
+			},
+			expectUsers: []contracts.DockerUser{
+				{Username: "reader", Password: "Reader!123", Contained: true, Schema: "reports"},
+				{Username: "writer", Password: "Writer!123"},
+			},
+		},
+		{
+			name:         "invalid user",
+			users:        []any{"reader"},
+			expectErrStr: "invalid user 0 string of the Sqlserver docker config",
+		},
+		{
+			name:         "invalid users",
+			users:        map[string]any{"username": "reader"},
+			expectErrStr: "invalid users map[string]interface {} of the Sqlserver docker config",
+		},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			s.mockConfig.EXPECT().Get(fmt.Sprintf("database.connections.%s.docker", s.connection)).Return(map[string]any{
+				"users": test.users,
+			}).Once()
+
+			dockerConfig, err := s.config.dockerConfig()
+			if test.expectErrStr != "" {
+				s.ErrorContains(err, test.expectErrStr)
+				return
+			}
+			s.NoError(err)
+			s.Equal(test.expectUsers, dockerConfig.Users)
+		})
+	}
+}
+
+func TestStaticConfig(t *testing.T) {
+	writer := contracts.FullConfig{
+		Config: contracts.Config{
+			Host:     "127.0.0.1",
+			Port:     1433,
+			Database: "goravel",
+			Username: "sa",
+			Password: "Framework!123",
+		},
+		Prefix: "goravel_",
+	}
+	config := NewStaticConfig([]contracts.FullConfig{writer}, nil)
+
+	assert.Nil(t, config.Config())
+	assert.Equal(t, "sqlserver", config.Connection())
+	assert.Nil(t, config.Readers())
+
+	writer.Connection = "sqlserver"
+	writer.Driver = Name
+	writer.Timezone = "UTC"
+	assert.Equal(t, []contracts.FullConfig{writer}, config.Writers())
+
+	config.setPort(14330)
+	assert.Equal(t, 14330, config.Writers()[0].Port)
+}
@@ -1,7 +1,10 @@
 package contracts
 
 import (
+	"time"
+
 	contractsconfig "github.com/goravel/framework/contracts/config"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type ConfigBuilder interface {
@@ -37,4 +40,59 @@ type FullConfig struct {
 	Prefix       string
 	Singular     bool
 	Timezone     string
+	// ParameterSizing normalizes the declarations of the string and binary parameters, "bucket" or empty
+	ParameterSizing string
+	// VarcharParameters sends the ASCII string parameters as varchar
+	VarcharParameters bool
+	// Unicode chooses the type of the string columns and parameters: "nvarchar" (default), "varchar" or "utf8"
+	Unicode string
+	// Docker configures the test container of the connection
+	Docker DockerConfig
+	// Metrics keeps the pools of the connection for the MetricsCollector
+	Metrics bool
+	// Tracing enables OpenTelemetry spans for every statement of the connection
+	Tracing bool
+	// TracerProvider is used when Tracing is enabled, the global provider is used when it is nil
+	TracerProvider trace.TracerProvider
+}
+
+// DockerConfig Used in the docker block of config/database.go, it configures the test container
+type DockerConfig struct {
+	// Image is the repository of the image, default is mcr.microsoft.com/mssql/server
+	Image string
+	// Tag is the tag of the image, default is 2022-latest
+	Tag string
+	// Edition is set to MSSQL_PID: Developer (default), Express, Standard, Enterprise, etc.
+	Edition string
+	// Collation is set to MSSQL_COLLATION, the server collation
+	Collation string
+	// Agent enables the SQL Server Agent
+	Agent bool
+	// MemoryLimit is set to MSSQL_MEMORY_LIMIT_MB
+	MemoryLimit int
+	// Contained creates the user of the connection as a contained database user without a login
+	Contained bool
+	// Roles are the database roles of the user of the connection, default is db_owner
+	Roles []string
+	// Schema is the default schema of the user of the connection, it's created if it doesn't exist
+	Schema string
+	// Users are the additional users provisioned in the database, to test the least-privilege behaviour
+	Users []DockerUser
+	// ReadyTimeout is the deadline of Ready, default is 2 minutes
+	ReadyTimeout time.Duration
+	// FullText requires the image to have Full-Text Search installed, Ready fails if it's not installed
+	FullText bool
+}
+
+// DockerUser is a database user provisioned in the test container
+type DockerUser struct {
+	Username string
+	Password string
+	// Contained creates a contained database user without a login
+	Contained bool
+	// Roles are the database roles of the user, they are created if they don't exist, the user only
+	// belongs to public if it's empty
+	Roles []string
+	// Schema is the default schema of the user, it's created if it doesn't exist
+	Schema string
 }
@@ -0,0 +1,155 @@
+package sqlserver
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"weak"
+
+	mssql "github.com/microsoft/go-mssqldb"
+	"gorm.io/driver/sqlserver"
+	"gorm.io/gorm"
+
+	"github.com/goravel/sqlserver/contracts"
+)
+
+var (
+	// pools keeps the connection pools of the connections with metrics by poolKey. A pool is removed when it's
+	// closed.
+	pools sync.Map
+	// poolID makes the key of every pool unique, even if two pools have the same config.
+	poolID atomic.Uint64
+	// prefixes keeps the table prefix of the pools opened by the driver, so the helpers that take a *sql.DB add
+	// it like the migrations. The pools are weakly referenced, an entry is removed when its pool is collected.
+	prefixes sync.Map
+)
+
+// Dialector wraps the gorm SQL Server dialector. When the tracing, the metrics or the parameter options are
+// enabled, it opens the connection pool through a go-mssqldb connector, so the statements can be instrumented
+// before they reach the driver.
+type Dialector struct {
+	*sqlserver.Dialector
+	fullConfig contracts.FullConfig
+	role       string
+	// index is the position of the config in the readers or the writers of the connection.
+	index int
+}
+
+func (r *Dialector) Initialize(db *gorm.DB) error {
+	options := ParameterOptions{Sizing: r.fullConfig.ParameterSizing, Varchar: r.fullConfig.VarcharParameters || r.fullConfig.Unicode == UnicodeVarchar || r.fullConfig.Unicode == UnicodeUTF8}
+	if !r.fullConfig.Tracing && !r.fullConfig.Metrics && !options.enabled() {
+		if err := r.Dialector.Initialize(db); err != nil {
+			return err
+		}
+		if sqlDB, ok := db.ConnPool.(*sql.DB); ok {
+			storePrefix(sqlDB, r.fullConfig.Prefix)
+		}
+
+		return nil
+	}
+
+	mssqlConnector, err := mssql.NewConnector(r.DSN)
+	if err != nil {
+		return err
+	}
+
+	var connector driver.Connector = mssqlConnector
+	if options.enabled() {
+		connector = newParameterConnector(connector, options)
+	}
+	if r.fullConfig.Tracing {
+		connector = newTracedConnector(connector, r.fullConfig, r.role)
+	}
+
+	var sqlDB *sql.DB
+	if r.fullConfig.Metrics {
+		sqlDB = openPool(newPoolKey(r.fullConfig, r.role, r.index), connector)
+	} else {
+		sqlDB = sql.OpenDB(connector)
+	}
+	config := *r.Config
+	config.Conn = sqlDB
+	if err := (sqlserver.Dialector{Config: &config}).Initialize(db); err != nil {
+		_ = sqlDB.Close()
+
+		return err
+	}
+	storePrefix(sqlDB, r.fullConfig.Prefix)
+
+	return nil
+}
+
+// openPool opens a connection pool and keeps it in pools for the metrics until it's closed.
+func openPool(key poolKey, connector driver.Connector) *sql.DB {
+	sqlDB := sql.OpenDB(&poolConnector{Connector: connector, key: key})
+	pools.Store(key, sqlDB)
+
+	return sqlDB
+}
+
+// storePrefix keeps the table prefix of a pool until the pool is collected.
+func storePrefix(db *sql.DB, prefix string) {
+	key := weak.Make(db)
+	prefixes.Store(key, prefix)
+	runtime.AddCleanup(db, func(key weak.Pointer[sql.DB]) {
+		prefixes.Delete(key)
+	}, key)
+}
+
+// tablePrefix returns the table prefix of a pool opened by the driver, it's empty for the other pools.
+func tablePrefix(db *sql.DB) string {
+	if prefix, ok := prefixes.Load(weak.Make(db)); ok {
+		return prefix.(string)
+	}
+
+	return ""
+}
+
+// poolConnector removes the pool from pools when it's closed, sql.DB closes its connector if it's an io.Closer.
+type poolConnector struct {
+	driver.Connector
+	key poolKey
+}
+
+func (r *poolConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return r.Connector.Connect(ctx)
+}
+
+func (r *poolConnector) Close() error {
+	pools.Delete(r.key)
+	if closer, ok := r.Connector.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+type poolKey struct {
+	Connection string
+	Role       string
+	Index      int
+	Host       string
+	Database   string
+	id         uint64
+}
+
+func newPoolKey(fullConfig contracts.FullConfig, role string, index int) poolKey {
+	host := fullConfig.Host
+	if fullConfig.Port > 0 {
+		host = fmt.Sprintf("%s:%d", fullConfig.Host, fullConfig.Port)
+	}
+
+	return poolKey{
+		Connection: fullConfig.Connection,
+		Role:       role,
+		Index:      index,
+		Host:       host,
+		Database:   fullConfig.Database,
+		id:         poolID.Add(1),
+	}
+}
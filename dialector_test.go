@@ -0,0 +1,66 @@
+package sqlserver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/goravel/sqlserver/contracts"
+)
+
+func TestDialectorInitialize(t *testing.T) {
+	tests := []struct {
+		name         string
+		fullConfig   contracts.FullConfig
+		expectPooled bool
+	}{
+		{
+			name:       "without instrumentation",
+			fullConfig: contracts.FullConfig{Connection: "plain"},
+		},
+		{
+			name:       "with tracing",
+			fullConfig: contracts.FullConfig{Connection: "tracing", Tracing: true},
+		},
+		{
+			name:       "with parameter options",
+			fullConfig: contracts.FullConfig{Connection: "parameters", ParameterSizing: ParameterSizingBucket},
+		},
+		{
+			name:         "with metrics",
+			fullConfig:   contracts.FullConfig{Connection: "metrics", Metrics: true},
+			expectPooled: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.fullConfig.Config = contracts.Config{Host: "127.0.0.1", Port: 1433, Database: "goravel", Username: "sa", Password: "Goravel123"}
+			test.fullConfig.Prefix = "goravel_"
+
+			db, err := gorm.Open(fullConfigToDialector(test.fullConfig, RoleWriter, 0), &gorm.Config{DisableAutomaticPing: true})
+			require.NoError(t, err)
+			sqlDB, err := db.DB()
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expectPooled, hasPool(test.fullConfig.Connection))
+			assert.Equal(t, "goravel_", tablePrefix(sqlDB))
+
+			assert.NoError(t, sqlDB.Close())
+			assert.False(t, hasPool(test.fullConfig.Connection))
+		})
+	}
+}
+
+func hasPool(connection string) bool {
+	var found bool
+	pools.Range(func(key, _ any) bool {
+		found = key.(poolKey).Connection == connection
+
+		return !found
+	})
+
+	return found
+}
@@ -1,23 +1,102 @@
 package sqlserver
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os/exec"
+	"slices"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	contractsdocker "github.com/goravel/framework/contracts/testing/docker"
 	supportdocker "github.com/goravel/framework/support/docker"
 	testingdocker "github.com/goravel/framework/testing/docker"
 	"github.com/goravel/sqlserver/contracts"
+	mssql "github.com/microsoft/go-mssqldb"
 	"github.com/spf13/cast"
 	"gorm.io/driver/sqlserver"
 	gormio "gorm.io/gorm"
 )
 
+const (
+	defaultReadyTimeout    = 2 * time.Minute
+	loginFailedErrorNumber = 18456
+	logsTimeout            = 10 * time.Second
+	maxReadyBackoff        = 5 * time.Second
+	readyBackoff           = 250 * time.Millisecond
+	readyLogLines          = 50
+)
+
+// dataDirectory is the data directory of the mcr.microsoft.com/mssql/server image.
+const dataDirectory = "/var/opt/mssql/data"
+
+type freshStep struct {
+	name string
+	// query returns the statements dropping the objects of the step.
+	query string
+}
+
+var freshSteps = []freshStep{
+	{
+		name: "foreign keys",
+		query: "select 'alter table ' + quotename(schema_name(t.schema_id)) + '.' + quotename(t.name) + ' drop constraint ' + quotename(fk.name) " +
+			"from sys.foreign_keys fk join sys.tables t on fk.parent_object_id = t.object_id where t.is_ms_shipped = 0",
+	},
+	{
+		name:  "system versioned tables",
+		query: "select 'alter table ' + quotename(schema_name(schema_id)) + '.' + quotename(name) + ' set (system_versioning = off)' from sys.tables where temporal_type = 2",
+	},
+	{
+		name:  "views",
+		query: "select 'drop view ' + quotename(schema_name(schema_id)) + '.' + quotename(name) from sys.views where is_ms_shipped = 0",
+	},
+	{
+		name:  "procedures",
+		query: "select 'drop procedure ' + quotename(schema_name(schema_id)) + '.' + quotename(name) from sys.procedures where is_ms_shipped = 0",
+	},
+	{
+		name:  "synonyms",
+		query: "select 'drop synonym ' + quotename(schema_name(schema_id)) + '.' + quotename(name) from sys.synonyms",
+	},
+	{
+		name:  "tables",
+		query: "select 'drop table ' + quotename(schema_name(schema_id)) + '.' + quotename(name) from sys.tables where is_ms_shipped = 0",
+	},
+	{
+		name: "functions",
+		query: "select 'drop function ' + quotename(schema_name(schema_id)) + '.' + quotename(name) from sys.objects " +
+			"where type in ('FN', 'IF', 'TF', 'FS', 'FT') and is_ms_shipped = 0",
+	},
+	{
+		name:  "sequences",
+		query: "select 'drop sequence ' + quotename(schema_name(schema_id)) + '.' + quotename(name) from sys.sequences",
+	},
+	{
+		name:  "types",
+		query: "select 'drop type ' + quotename(schema_name(schema_id)) + '.' + quotename(name) from sys.types where is_user_defined = 1",
+	},
+	{
+		// dbo, guest, INFORMATION_SCHEMA and sys are 1 to 4, the schemas of the fixed roles start from 16384.
+		name:  "schemas",
+		query: "select 'drop schema ' + quotename(name) from sys.schemas where schema_id > 4 and schema_id < 16384",
+	},
+}
+
 type Docker struct {
+	clones         []string
+	clonesLock     sync.Mutex
 	config         contracts.ConfigBuilder
 	databaseConfig contractsdocker.DatabaseConfig
+	dockerConfig   contracts.DockerConfig
+	fixtures       []Fixture
+	scriptDir      string
+	scriptVars     map[string]string
 	imageDriver    contractsdocker.ImageDriver
+	readers        []*Docker
+	template       bool
 }
 
 func NewDocker(config contracts.ConfigBuilder, database, username, password string) *Docker {
@@ -31,18 +110,18 @@ func NewDocker(config contracts.ConfigBuilder, database, username, password stri
 			Port:     1433,
 			Username: username,
 		},
-		imageDriver: testingdocker.NewImageDriver(contractsdocker.Image{
-			Repository: "mcr.microsoft.com/mssql/server",
-			Tag:        "latest",
-			Env: []string{
-				"ACCEPT_EULA=Y",
-				"MSSQL_SA_PASSWORD=" + password,
-			},
-			ExposedPorts: []string{"1433"},
-		}),
+		imageDriver: testingdocker.NewImageDriver(dockerImage(contracts.DockerConfig{}, password)),
 	}
 }
 
+// Configure sets the image and the features of the container, it should be called before Build.
+func (r *Docker) Configure(dockerConfig contracts.DockerConfig) *Docker {
+	r.dockerConfig = dockerConfig
+	r.imageDriver = testingdocker.NewImageDriver(dockerImage(dockerConfig, r.databaseConfig.Password))
+
+	return r
+}
+
 func (r *Docker) Build() error {
 	if err := r.imageDriver.Build(); err != nil {
 		return err
@@ -52,6 +131,12 @@ func (r *Docker) Build() error {
 	r.databaseConfig.ContainerID = config.ContainerID
 	r.databaseConfig.Port = cast.ToInt(supportdocker.ExposedPort(config.ExposedPorts, strconv.Itoa(r.databaseConfig.Port)))
 
+	for i, reader := range r.readers {
+		if err := reader.Build(); err != nil {
+			return fmt.Errorf("build Sqlserver reader %d error: %v", i, err)
+		}
+	}
+
 	return nil
 }
 
@@ -60,7 +145,11 @@ func (r *Docker) Config() contractsdocker.DatabaseConfig {
 }
 
 func (r *Docker) Database(name string) (contractsdocker.DatabaseDriver, error) {
-	docker := NewDocker(r.config, name, r.databaseConfig.Username, r.databaseConfig.Password)
+	if r.template {
+		return r.Clone(name)
+	}
+
+	docker := NewDocker(r.config, name, r.databaseConfig.Username, r.databaseConfig.Password).Configure(r.dockerConfig)
 	docker.databaseConfig.ContainerID = r.databaseConfig.ContainerID
 	docker.databaseConfig.Port = r.databaseConfig.Port
 
@@ -71,31 +160,28 @@ func (r *Docker) Driver() string {
 	return Name
 }
 
+// Fresh drops every user object of the database in dependency order: foreign keys, views, procedures,
+// synonyms, tables, functions, sequences, types and schemas. The identity seeds and sequences start over
+// because the objects are recreated by the migrations. All failures are reported together.
 func (r *Docker) Fresh() error {
 	instance, err := r.connect()
 	if err != nil {
 		return fmt.Errorf("connect Sqlserver error when clearing: %v", err)
 	}
 
-	res := instance.Raw("SELECT NAME FROM SYSOBJECTS WHERE TYPE='U';")
-	if res.Error != nil {
-		return fmt.Errorf("get tables of Sqlserver error: %v", res.Error)
+	var errs []error
+	for _, step := range r.freshSteps() {
+		errs = append(errs, r.freshStep(instance, step)...)
 	}
 
-	var tables []string
-	res = res.Scan(&tables)
-	if res.Error != nil {
-		return fmt.Errorf("get tables of Sqlserver error: %v", res.Error)
+	if err := r.close(instance); err != nil {
+		errs = append(errs, err)
 	}
-
-	for _, table := range tables {
-		res = instance.Exec(fmt.Sprintf("drop table %s;", table))
-		if res.Error != nil {
-			return fmt.Errorf("drop table %s of Sqlserver error: %v", table, res.Error)
-		}
+	if len(errs) == 0 {
+		errs = append(errs, r.Sync())
 	}
 
-	return r.close(instance)
+	return errors.Join(errs...)
 }
 
 func (r *Docker) Image(image contractsdocker.Image) {
@@ -103,16 +189,77 @@ func (r *Docker) Image(image contractsdocker.Image) {
 }
 
 func (r *Docker) Ready() error {
-	gormDB, err := r.connect()
+	ctx, cancel := context.WithTimeout(context.Background(), r.readyTimeout())
+	defer cancel()
+
+	return r.ReadyContext(ctx)
+}
+
+// ReadyContext checks if the database is ready until the context is done, see Ready.
+func (r *Docker) ReadyContext(ctx context.Context) error {
+	gormDB, err := r.connectContext(ctx)
 	if err != nil {
 		return err
 	}
 
+	if r.dockerConfig.FullText {
+		var installed bool
+		if err := gormDB.Raw("select cast(fulltextserviceproperty('IsFullTextInstalled') as bit)").Scan(&installed).Error; err != nil {
+			return err
+		}
+		if !installed {
+			return fmt.Errorf("full-text search is not installed in the Sqlserver image %s", r.image())
+		}
+	}
+
+	if err := r.close(gormDB); err != nil {
+		return err
+	}
+
+	for _, fixture := range r.fixtures {
+		if err := r.RestoreFixture(fixture); err != nil {
+			return err
+		}
+	}
+
+	if r.scriptDir != "" {
+		if err := r.execScripts(ctx); err != nil {
+			return err
+		}
+	}
+
+	if readers := r.builtReaders(); len(readers) > 0 {
+		for i, reader := range readers {
+			readerDB, err := reader.connectContext(ctx)
+			if err != nil {
+				return fmt.Errorf("wait for Sqlserver reader %d error: %w", i, err)
+			}
+			if err := reader.close(readerDB); err != nil {
+				return err
+			}
+		}
+		if err := r.Sync(); err != nil {
+			return err
+		}
+		r.resetReaderPorts()
+	}
+
 	r.resetConfigPort()
 
-	return r.close(gormDB)
+	return nil
 }
 
+// Scripts sets a directory of .sql files run by Ready after the fixtures are restored, they are run by sa
+// in the database in the order of their names, see ExecScript.
+func (r *Docker) Scripts(dir string, vars map[string]string) *Docker {
+	r.scriptDir = dir
+	r.scriptVars = vars
+
+	return r
+}
+
+// Reuse reuses the container of the writer, the readers are not started by it, reuse them by ReuseReader or
+// they are ignored.
 func (r *Docker) Reuse(containerID string, port int) error {
 	r.databaseConfig.ContainerID = containerID
 	r.databaseConfig.Port = port
@@ -120,69 +267,323 @@ func (r *Docker) Reuse(containerID string, port int) error {
 	return nil
 }
 
+// ReuseReader reuses the container of the reader at the index of the reader configs.
+func (r *Docker) ReuseReader(index int, containerID string, port int) error {
+	if index < 0 || index >= len(r.readers) {
+		return fmt.Errorf("the Sqlserver reader %d is not configured", index)
+	}
+
+	return r.readers[index].Reuse(containerID, port)
+}
+
+// Snapshot creates a database snapshot of the current database, it can be restored by RestoreSnapshot.
+// An existing snapshot with the same name is replaced. If the server can't create snapshots, the
+// database is backed up to a file inside the container instead.
+func (r *Docker) Snapshot(name string) error {
+	instance, err := r.connectMaster()
+	if err != nil {
+		return fmt.Errorf("connect Sqlserver error when snapshotting: %v", err)
+	}
+	defer r.close(instance)
+
+	snapshot := r.snapshotName(name)
+	if err := instance.Exec(fmt.Sprintf("if db_id(%s) is not null drop database %s", quoteString(snapshot), quoteIdentifier(snapshot))).Error; err != nil {
+		return fmt.Errorf("drop snapshot %s of Sqlserver error: %v", snapshot, err)
+	}
+
+	var files []string
+	if err := instance.Raw("select name from sys.master_files where database_id = db_id(?) and type = 0", r.databaseConfig.Database).Scan(&files).Error; err != nil {
+		return fmt.Errorf("get files of Sqlserver error: %v", err)
+	}
+
+	specs := make([]string, len(files))
+	for i, file := range files {
+		specs[i] = fmt.Sprintf("(name = %s, filename = %s)", quoteIdentifier(file), quoteString(fmt.Sprintf("%s/%s_%s.ss", dataDirectory, snapshot, file)))
+	}
+
+	snapshotErr := instance.Exec(fmt.Sprintf("create database %s on %s as snapshot of %s",
+		quoteIdentifier(snapshot), strings.Join(specs, ", "), quoteIdentifier(r.databaseConfig.Database))).Error
+	if snapshotErr == nil {
+		return nil
+	}
+
+	if err := instance.Exec(fmt.Sprintf("backup database %s to disk = %s with init, copy_only",
+		quoteIdentifier(r.databaseConfig.Database), quoteString(r.backupFile(snapshot)))).Error; err != nil {
+		return fmt.Errorf("snapshot %s of Sqlserver error: %w", snapshot, errors.Join(snapshotErr, err))
+	}
+
+	return nil
+}
+
+// RestoreSnapshot resets the current database to a snapshot created by Snapshot, the other
+// connections to the database are closed. The snapshot is kept, so it can be restored again, but
+// the other snapshots of the database are dropped since SQL Server can't restore a database that
+// has several.
+func (r *Docker) RestoreSnapshot(name string) error {
+	instance, err := r.connectMaster()
+	if err != nil {
+		return fmt.Errorf("connect Sqlserver error when restoring: %v", err)
+	}
+	defer r.close(instance)
+
+	snapshot := r.snapshotName(name)
+	var exists bool
+	if err := instance.Raw("select cast(count(*) as bit) from sys.databases where name = ? and source_database_id = db_id(?)", snapshot, r.databaseConfig.Database).Scan(&exists).Error; err != nil {
+		return fmt.Errorf("get snapshot %s of Sqlserver error: %v", snapshot, err)
+	}
+
+	// SQL Server restores a database from a snapshot only when it's the single snapshot of the database.
+	restore := fmt.Sprintf("%s; restore database %s from database_snapshot = %s",
+		dropOtherSnapshots(r.databaseConfig.Database, snapshot), quoteIdentifier(r.databaseConfig.Database), quoteString(snapshot))
+	if !exists {
+		restore = fmt.Sprintf("restore database %s from disk = %s with replace", quoteIdentifier(r.databaseConfig.Database), quoteString(r.backupFile(snapshot)))
+	}
+
+	database := quoteIdentifier(r.databaseConfig.Database)
+	if err := instance.Exec(fmt.Sprintf("alter database %s set single_user with rollback immediate", database)).Error; err != nil {
+		return fmt.Errorf("restore snapshot %s of Sqlserver error: %v", snapshot, err)
+	}
+
+	restoreErr := instance.Exec(restore).Error
+	if err := instance.Exec(fmt.Sprintf("alter database %s set multi_user", database)).Error; err != nil {
+		restoreErr = errors.Join(restoreErr, err)
+	}
+	if restoreErr != nil {
+		return fmt.Errorf("restore snapshot %s of Sqlserver error: %w", snapshot, restoreErr)
+	}
+
+	return nil
+}
+
 func (r *Docker) Shutdown() error {
-	return r.imageDriver.Shutdown()
+	errs := []error{r.imageDriver.Shutdown()}
+	for _, reader := range r.builtReaders() {
+		errs = append(errs, reader.Shutdown())
+	}
+
+	return errors.Join(errs...)
 }
 
 func (r *Docker) connect() (*gormio.DB, error) {
-	var (
-		instance *gormio.DB
-		err      error
-	)
+	ctx, cancel := context.WithTimeout(context.Background(), r.readyTimeout())
+	defer cancel()
 
-	// docker compose need time to start
-	for i := 0; i < 100; i++ {
+	return r.connectContext(ctx)
+}
+
+// connectContext waits for the server to accept logins, provisions the database and the user, and waits
+// for the database to be ONLINE. A failed login of sa returns immediately, the other errors are retried
+// with an exponential backoff until the context is done.
+func (r *Docker) connectContext(ctx context.Context) (*gormio.DB, error) {
+	var master *gormio.DB
+	if err := r.retry(ctx, func() (err error) {
+		master, err = r.connectMaster()
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	defer r.close(master)
+
+	if err := r.provision(master); err != nil {
+		return nil, err
+	}
+
+	if err := r.retry(ctx, func() error {
+		var state string
+		if err := master.Raw("select state_desc from sys.databases where name = ?", r.databaseConfig.Database).Scan(&state).Error; err != nil {
+			return err
+		}
+		if state != "ONLINE" {
+			return fmt.Errorf("database %s is %s", r.databaseConfig.Database, state)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	var instance *gormio.DB
+	if err := r.retry(ctx, func() (err error) {
 		instance, err = gormio.Open(sqlserver.New(sqlserver.Config{
-			DSN: fmt.Sprintf("sqlserver://%s:%s@%s:%d?database=master",
-				"sa", r.databaseConfig.Password, r.databaseConfig.Host, r.databaseConfig.Port),
+			DSN: fmt.Sprintf("sqlserver://%s:%s@%s:%d?database=%s",
+				r.databaseConfig.Username, r.databaseConfig.Password, r.databaseConfig.Host, r.databaseConfig.Port, r.databaseConfig.Database),
 		}))
+		return err
+	}); err != nil {
+		return nil, err
+	}
 
+	return instance, nil
+}
+
+// retry calls fn with an exponential backoff until it succeeds, the login fails or the context is done.
+// The distinct errors and the recent container logs are returned on timeout.
+func (r *Docker) retry(ctx context.Context, fn func() error) error {
+	var (
+		backoff = readyBackoff
+		errs    []error
+		seen    = make(map[string]bool)
+	)
+
+	for {
+		err := fn()
 		if err == nil {
-			// Check if database exists
-			var exists bool
-			query := fmt.Sprintf("SELECT CASE WHEN EXISTS (SELECT * FROM sys.databases WHERE name = '%s') THEN CAST(1 AS BIT) ELSE CAST(0 AS BIT) END", r.databaseConfig.Database)
-			if err := instance.Raw(query).Scan(&exists).Error; err != nil {
-				return nil, err
-			}
+			return nil
+		}
+
+		var sqlErr mssql.Error
+		if errors.As(err, &sqlErr) && sqlErr.Number == loginFailedErrorNumber {
+			return fmt.Errorf("login to Sqlserver failed, please check the username and password: %w", err)
+		}
 
-			if !exists {
-				// Create User database
-				if err := instance.Exec(fmt.Sprintf(`CREATE DATABASE "%s";`, r.databaseConfig.Database)).Error; err != nil {
-					return nil, err
-				}
-
-				if err := instance.Exec(fmt.Sprintf(`
-IF NOT EXISTS (SELECT 1 FROM sys.server_principals WHERE name = '%s' AND type = 'S')
-BEGIN
-    CREATE LOGIN %s WITH PASSWORD = '%s';
-END
-				`, r.databaseConfig.Username, r.databaseConfig.Username, r.databaseConfig.Password)).Error; err != nil {
-					return nil, err
-				}
-
-				// Create DB account for User
-				if err := instance.Exec(fmt.Sprintf("USE %s; CREATE USER %s FOR LOGIN %s", r.databaseConfig.Database, r.databaseConfig.Username, r.databaseConfig.Username)).Error; err != nil {
-					return nil, err
-				}
-
-				// Add permission
-				if err := instance.Exec(fmt.Sprintf("USE %s; ALTER ROLE db_owner ADD MEMBER %s", r.databaseConfig.Database, r.databaseConfig.Username)).Error; err != nil {
-					return nil, err
-				}
+		if !seen[err.Error()] {
+			seen[err.Error()] = true
+			errs = append(errs, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			errs = append([]error{fmt.Errorf("wait for Sqlserver to be ready error: %w", ctx.Err())}, errs...)
+			if logs := r.logs(); logs != "" {
+				errs = append(errs, fmt.Errorf("container logs:\n%s", logs))
 			}
 
-			instance, err = gormio.Open(sqlserver.New(sqlserver.Config{
-				DSN: fmt.Sprintf("sqlserver://%s:%s@%s:%d?database=%s",
-					r.databaseConfig.Username, r.databaseConfig.Password, r.databaseConfig.Host, r.databaseConfig.Port, r.databaseConfig.Database),
-			}))
+			return errors.Join(errs...)
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, maxReadyBackoff)
+	}
+}
+
+// logs returns the recent error log of the container.
+func (r *Docker) logs() string {
+	if r.databaseConfig.ContainerID == "" {
+		return ""
+	}
 
-			break
+	ctx, cancel := context.WithTimeout(context.Background(), logsTimeout)
+	defer cancel()
+
+	// The server writes its log to stderr, and the lines are kept as they are.
+	output, err := exec.CommandContext(ctx, "docker", "logs", "--tail", strconv.Itoa(readyLogLines), r.databaseConfig.ContainerID).CombinedOutput()
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(output))
+}
+
+func (r *Docker) readyTimeout() time.Duration {
+	if r.dockerConfig.ReadyTimeout > 0 {
+		return r.dockerConfig.ReadyTimeout
+	}
+
+	return defaultReadyTimeout
+}
+
+// freshStep drops the objects of a step, the failed drops are retried while there is progress,
+// so the objects depending on each other (views on views, for example) are dropped eventually.
+// freshSteps returns the steps of Fresh, the schemas provisioned for the users are kept since they are their
+// default schemas.
+func (r *Docker) freshSteps() []freshStep {
+	names := []string{r.dockerConfig.Schema}
+	for _, user := range r.dockerConfig.Users {
+		names = append(names, user.Schema)
+	}
+
+	var schemas []string
+	for _, schema := range names {
+		if schema != "" && !slices.Contains(schemas, quoteString(schema)) {
+			schemas = append(schemas, quoteString(schema))
 		}
+	}
+	if len(schemas) == 0 {
+		return freshSteps
+	}
 
-		time.Sleep(1 * time.Second)
+	steps := slices.Clone(freshSteps)
+	for i, step := range steps {
+		if step.name == "schemas" {
+			steps[i].query = fmt.Sprintf("%s and name not in (%s)", step.query, strings.Join(schemas, ", "))
+		}
 	}
 
-	return instance, err
+	return steps
+}
+
+func (r *Docker) freshStep(instance *gormio.DB, step freshStep) []error {
+	var statements []string
+	if err := instance.Raw(step.query).Scan(&statements).Error; err != nil {
+		return []error{fmt.Errorf("get %s of Sqlserver error: %v", step.name, err)}
+	}
+
+	for len(statements) > 0 {
+		var (
+			errs   []error
+			failed []string
+		)
+		for _, statement := range statements {
+			if err := instance.Exec(statement).Error; err != nil {
+				errs = append(errs, fmt.Errorf("%s of Sqlserver error: %v", statement, err))
+				failed = append(failed, statement)
+			}
+		}
+		if len(failed) == 0 || len(failed) == len(statements) {
+			return errs
+		}
+
+		statements = failed
+	}
+
+	return nil
+}
+
+func (r *Docker) image() string {
+	image := dockerImage(r.dockerConfig, "")
+
+	return image.Repository + ":" + image.Tag
+}
+
+func (r *Docker) backupFile(snapshot string) string {
+	return fmt.Sprintf("%s/%s.bak", dataDirectory, snapshot)
+}
+
+// dropOtherSnapshots drops the snapshots of the database except the snapshot.
+func dropOtherSnapshots(database, snapshot string) string {
+	return fmt.Sprintf("declare @snapshots nvarchar(max) = N''; "+
+		"select @snapshots += N'drop database ' + quotename(name) + N';' from sys.databases "+
+		"where source_database_id = db_id(%s) and name <> %s; "+
+		"exec(@snapshots)", quoteString(database), quoteString(snapshot))
+}
+
+func (r *Docker) snapshotName(name string) string {
+	return r.databaseConfig.Database + "_" + name
+}
+
+func (r *Docker) execScripts(ctx context.Context) error {
+	instance, err := gormio.Open(sqlserver.New(sqlserver.Config{
+		DSN: fmt.Sprintf("sqlserver://%s:%s@%s:%d?database=%s",
+			"sa", r.databaseConfig.Password, r.databaseConfig.Host, r.databaseConfig.Port, r.databaseConfig.Database),
+	}))
+	if err != nil {
+		return fmt.Errorf("connect Sqlserver error when running scripts: %v", err)
+	}
+	defer r.close(instance)
+
+	db, err := instance.DB()
+	if err != nil {
+		return err
+	}
+
+	return ExecScriptDir(ctx, db, r.scriptDir, r.scriptVars)
+}
+
+func (r *Docker) connectMaster() (*gormio.DB, error) {
+	return gormio.Open(sqlserver.New(sqlserver.Config{
+		DSN: fmt.Sprintf("sqlserver://%s:%s@%s:%d?database=master",
+			"sa", r.databaseConfig.Password, r.databaseConfig.Host, r.databaseConfig.Port),
+	}))
 }
 
 func (r *Docker) close(gormDB *gormio.DB) error {
@@ -195,6 +596,14 @@ func (r *Docker) close(gormDB *gormio.DB) error {
 }
 
 func (r *Docker) resetConfigPort() {
+	if r.config.Config() == nil {
+		if setter, ok := r.config.(interface{ setPort(int) }); ok {
+			setter.setPort(r.databaseConfig.Port)
+		}
+
+		return
+	}
+
 	writers := r.config.Config().Get(fmt.Sprintf("database.connections.%s.write", r.config.Connection()))
 	if writeConfigs, ok := writers.([]contracts.Config); ok {
 		writeConfigs[0].Port = r.databaseConfig.Port
@@ -205,3 +614,43 @@ func (r *Docker) resetConfigPort() {
 
 	r.config.Config().Add(fmt.Sprintf("database.connections.%s.port", r.config.Connection()), r.databaseConfig.Port)
 }
+
+func dockerImage(dockerConfig contracts.DockerConfig, password string) contractsdocker.Image {
+	image := contractsdocker.Image{
+		Repository: dockerConfig.Image,
+		Tag:        dockerConfig.Tag,
+		Env: []string{
+			"ACCEPT_EULA=Y",
+			"MSSQL_SA_PASSWORD=" + password,
+		},
+		ExposedPorts: []string{"1433"},
+	}
+	if image.Repository == "" {
+		image.Repository = "mcr.microsoft.com/mssql/server"
+	}
+	if image.Tag == "" {
+		image.Tag = "2022-latest"
+	}
+	if dockerConfig.Edition != "" {
+		image.Env = append(image.Env, "MSSQL_PID="+dockerConfig.Edition)
+	}
+	if dockerConfig.Collation != "" {
+		image.Env = append(image.Env, "MSSQL_COLLATION="+dockerConfig.Collation)
+	}
+	if dockerConfig.Agent {
+		image.Env = append(image.Env, "MSSQL_AGENT_ENABLED=true")
+	}
+	if dockerConfig.MemoryLimit > 0 {
+		image.Env = append(image.Env, "MSSQL_MEMORY_LIMIT_MB="+strconv.Itoa(dockerConfig.MemoryLimit))
+	}
+
+	return image
+}
+
+func quoteIdentifier(name string) string {
+	return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+}
+
+func quoteString(value string) string {
+	return "N'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
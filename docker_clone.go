@@ -0,0 +1,73 @@
+package sqlserver
+
+import (
+	"errors"
+	"fmt"
+)
+
+// AsTemplate makes Database(name) clone the current database instead of creating an empty one, so each
+// parallel test package gets an isolated copy of a migrated and seeded database in one container.
+func (r *Docker) AsTemplate() *Docker {
+	r.template = true
+
+	return r
+}
+
+// Clone copies the current database into a new database by backup and restore, the database is replaced
+// if it exists. The clones are dropped by DropClones.
+func (r *Docker) Clone(name string) (*Docker, error) {
+	instance, err := r.connectMaster()
+	if err != nil {
+		return nil, fmt.Errorf("connect Sqlserver error when cloning: %v", err)
+	}
+	defer r.close(instance)
+
+	file := fmt.Sprintf("%s/%s_clone_%s.bak", dataDirectory, r.databaseConfig.Database, name)
+	if err := instance.Exec(fmt.Sprintf("backup database %s to disk = %s with init, copy_only",
+		quoteIdentifier(r.databaseConfig.Database), quoteString(file))).Error; err != nil {
+		return nil, fmt.Errorf("backup Sqlserver database %s error: %v", r.databaseConfig.Database, err)
+	}
+	if err := r.restoreBak(instance, name, file); err != nil {
+		return nil, fmt.Errorf("clone Sqlserver database %s to %s error: %v", r.databaseConfig.Database, name, err)
+	}
+	if err := r.provisionUsers(instance, name); err != nil {
+		return nil, err
+	}
+
+	r.clonesLock.Lock()
+	r.clones = append(r.clones, name)
+	r.clonesLock.Unlock()
+
+	docker := NewDocker(r.config, name, r.databaseConfig.Username, r.databaseConfig.Password).Configure(r.dockerConfig)
+	docker.databaseConfig.ContainerID = r.databaseConfig.ContainerID
+	docker.databaseConfig.Port = r.databaseConfig.Port
+
+	return docker, nil
+}
+
+// DropClones drops the databases created by Clone, it's usually called when the tests finish.
+func (r *Docker) DropClones() error {
+	r.clonesLock.Lock()
+	clones := r.clones
+	r.clones = nil
+	r.clonesLock.Unlock()
+
+	if len(clones) == 0 {
+		return nil
+	}
+
+	instance, err := r.connectMaster()
+	if err != nil {
+		return fmt.Errorf("connect Sqlserver error when dropping clones: %v", err)
+	}
+	defer r.close(instance)
+
+	var errs []error
+	for _, clone := range clones {
+		if err := r.dropDatabase(instance, clone); err != nil {
+			errs = append(errs, fmt.Errorf("drop Sqlserver database %s error: %v", clone, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
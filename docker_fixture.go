@@ -0,0 +1,128 @@
+package sqlserver
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/goravel/framework/support/process"
+	"github.com/spf13/cast"
+	gormio "gorm.io/gorm"
+)
+
+// sqlpackagePaths are the places sqlpackage is looked up in the container, it's not shipped in the
+// official images, so the .bacpac fixtures require a custom image.
+var sqlpackagePaths = []string{"sqlpackage", "/opt/sqlpackage/sqlpackage", "/opt/mssql-tools/bin/sqlpackage"}
+
+// Fixture is a backup restored into the test container when it's ready, see Docker.Fixtures.
+type Fixture struct {
+	// Path is the local path of a .bak or .bacpac file.
+	Path string
+	// Database is the database restored into, the database of the Docker driver is used if it's empty.
+	Database string
+}
+
+// Fixtures sets the backups restored by Ready, they are restored in order after the database is created.
+func (r *Docker) Fixtures(fixtures ...Fixture) *Docker {
+	r.fixtures = fixtures
+
+	return r
+}
+
+// RestoreFixture copies a .bak or .bacpac file into the container and restores it, the database is replaced
+// if it exists. The logical files of a .bak are moved to the data directory by RESTORE FILELISTONLY, and
+// the user of the Docker driver is mapped to the restored database.
+func (r *Docker) RestoreFixture(fixture Fixture) error {
+	if fixture.Database == "" {
+		fixture.Database = r.databaseConfig.Database
+	}
+
+	ext := strings.ToLower(filepath.Ext(fixture.Path))
+	if ext != ".bak" && ext != ".bacpac" {
+		return fmt.Errorf("unsupported fixture %s of Sqlserver, only .bak and .bacpac are supported", fixture.Path)
+	}
+
+	target := fmt.Sprintf("%s/fixture_%s%s", dataDirectory, fixture.Database, ext)
+	if err := r.copyToContainer(fixture.Path, target); err != nil {
+		return fmt.Errorf("copy fixture %s to Sqlserver error: %v", fixture.Path, err)
+	}
+
+	instance, err := r.connectMaster()
+	if err != nil {
+		return fmt.Errorf("connect Sqlserver error when restoring fixture: %v", err)
+	}
+	defer r.close(instance)
+
+	if ext == ".bak" {
+		err = r.restoreBak(instance, fixture.Database, target)
+	} else {
+		err = r.importBacpac(instance, fixture.Database, target)
+	}
+	if err != nil {
+		return fmt.Errorf("restore fixture %s of Sqlserver error: %v", fixture.Path, err)
+	}
+
+	// The users of a backup are orphaned, because the logins of the source server don't exist in the container.
+	return r.provisionUsers(instance, fixture.Database)
+}
+
+func (r *Docker) restoreBak(instance *gormio.DB, database, file string) error {
+	var files []map[string]any
+	if err := instance.Raw(fmt.Sprintf("restore filelistonly from disk = %s", quoteString(file))).Scan(&files).Error; err != nil {
+		return err
+	}
+
+	moves := make([]string, len(files))
+	for i, file := range files {
+		logicalName := cast.ToString(file["LogicalName"])
+		moves[i] = fmt.Sprintf("move %s to %s", quoteString(logicalName),
+			quoteString(fmt.Sprintf("%s/%s_%s%s", dataDirectory, database, logicalName, filepath.Ext(cast.ToString(file["PhysicalName"])))))
+	}
+
+	if err := r.dropDatabase(instance, database); err != nil {
+		return err
+	}
+
+	return instance.Exec(fmt.Sprintf("restore database %s from disk = %s with replace, recovery, %s",
+		quoteIdentifier(database), quoteString(file), strings.Join(moves, ", "))).Error
+}
+
+func (r *Docker) importBacpac(instance *gormio.DB, database, file string) error {
+	sqlpackage, err := process.Run(fmt.Sprintf("docker exec %s sh -c %s", r.databaseConfig.ContainerID,
+		shellQuote(fmt.Sprintf("for path in %s; do command -v $path && break; done", strings.Join(sqlpackagePaths, " ")))))
+	if err != nil || sqlpackage == "" {
+		return fmt.Errorf("sqlpackage is not found in the image, it's required by the .bacpac fixtures")
+	}
+
+	// sqlpackage imports into a new database only.
+	if err := r.dropDatabase(instance, database); err != nil {
+		return err
+	}
+
+	_, err = process.Run(fmt.Sprintf("docker exec %s %s /Action:Import /SourceFile:%s /TargetServerName:localhost /TargetDatabaseName:%s /TargetUser:sa /TargetPassword:%s /TargetTrustServerCertificate:True",
+		r.databaseConfig.ContainerID, sqlpackage, shellQuote(file), shellQuote(database), shellQuote(r.databaseConfig.Password)))
+
+	return err
+}
+
+func (r *Docker) dropDatabase(instance *gormio.DB, database string) error {
+	return instance.Exec(fmt.Sprintf("if db_id(%s) is not null begin alter database %s set single_user with rollback immediate; drop database %s; end",
+		quoteString(database), quoteIdentifier(database), quoteIdentifier(database))).Error
+}
+
+// copyToContainer copies a local file into the container, readable by the server: docker cp creates the file as
+// root, the server runs as mssql.
+func (r *Docker) copyToContainer(file, target string) error {
+	if _, err := process.Run(fmt.Sprintf("docker cp %s %s:%s", shellQuote(file), r.databaseConfig.ContainerID, shellQuote(target))); err != nil {
+		return err
+	}
+	if _, err := process.Run(fmt.Sprintf("docker exec -u root %s chmod 644 %s", r.databaseConfig.ContainerID, shellQuote(target))); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
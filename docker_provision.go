@@ -0,0 +1,95 @@
+package sqlserver
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	gormio "gorm.io/gorm"
+
+	"github.com/goravel/sqlserver/contracts"
+)
+
+// provision creates the database and the users if they don't exist, every identifier and literal is quoted.
+func (r *Docker) provision(instance *gormio.DB) error {
+	database := r.databaseConfig.Database
+	if err := instance.Exec(fmt.Sprintf("if db_id(%s) is null create database %s", quoteString(database), quoteIdentifier(database))).Error; err != nil {
+		return fmt.Errorf("create Sqlserver database %s error: %v", database, err)
+	}
+
+	return r.provisionUsers(instance, database)
+}
+
+// provisionUsers creates the user of the connection and the additional users in a database, the existing users
+// are mapped to their logins again, so the orphaned users of a restored backup work.
+func (r *Docker) provisionUsers(instance *gormio.DB, database string) error {
+	roles := r.dockerConfig.Roles
+	if len(roles) == 0 {
+		roles = []string{"db_owner"}
+	}
+
+	users := []contracts.DockerUser{{
+		Username:  r.databaseConfig.Username,
+		Password:  r.databaseConfig.Password,
+		Contained: r.dockerConfig.Contained,
+		Roles:     roles,
+		Schema:    r.dockerConfig.Schema,
+	}}
+	users = append(users, r.dockerConfig.Users...)
+
+	if slices.ContainsFunc(users, func(user contracts.DockerUser) bool { return user.Contained }) {
+		if err := instance.Exec(fmt.Sprintf("exec sp_configure 'contained database authentication', 1; reconfigure; "+
+			"if (select containment from sys.databases where name = %s) = 0 alter database %s set containment = partial with rollback immediate;",
+			quoteString(database), quoteIdentifier(database))).Error; err != nil {
+			return fmt.Errorf("enable containment of Sqlserver database %s error: %v", database, err)
+		}
+	}
+
+	for _, user := range users {
+		if user.Username == "" || strings.EqualFold(user.Username, "sa") {
+			continue
+		}
+
+		for _, statement := range provisionUserStatements(user) {
+			if err := instance.Exec(fmt.Sprintf("use %s; %s", quoteIdentifier(database), statement)).Error; err != nil {
+				return fmt.Errorf("provision Sqlserver user %s in database %s error: %v", user.Username, database, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func provisionUserStatements(user contracts.DockerUser) []string {
+	username := quoteIdentifier(user.Username)
+
+	var statements []string
+	if user.Contained {
+		statements = append(statements, fmt.Sprintf("if user_id(%s) is null create user %s with password = %s",
+			quoteString(user.Username), username, quoteString(user.Password)))
+	} else {
+		statements = append(statements,
+			fmt.Sprintf("if suser_id(%s) is null create login %s with password = %s, check_policy = off",
+				quoteString(user.Username), username, quoteString(user.Password)),
+			fmt.Sprintf("if user_id(%s) is null create user %s for login %s else alter user %s with login = %s",
+				quoteString(user.Username), username, username, username, username),
+		)
+	}
+
+	if user.Schema != "" {
+		// create schema must be the only statement of a batch.
+		statements = append(statements,
+			fmt.Sprintf("if schema_id(%s) is null exec(%s)", quoteString(user.Schema), quoteString("create schema "+quoteIdentifier(user.Schema))),
+			fmt.Sprintf("alter user %s with default_schema = %s", username, quoteIdentifier(user.Schema)),
+		)
+	}
+
+	for _, role := range user.Roles {
+		statements = append(statements,
+			fmt.Sprintf("if database_principal_id(%s) is null create role %s", quoteString(role), quoteIdentifier(role)),
+			fmt.Sprintf("alter role %s add member %s", quoteIdentifier(role), username),
+		)
+	}
+
+	return statements
+}
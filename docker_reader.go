@@ -0,0 +1,144 @@
+package sqlserver
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	contractsdocker "github.com/goravel/framework/contracts/testing/docker"
+	"github.com/goravel/framework/support/process"
+
+	"github.com/goravel/sqlserver/contracts"
+)
+
+// Readers starts a container per reader besides the container of the writer, so the read/write splitting of
+// the connection is exercised by the tests. The readers are readable secondaries seeded by Sync, it should be
+// called before Build.
+func (r *Docker) Readers(readers ...contracts.FullConfig) *Docker {
+	r.readers = make([]*Docker, len(readers))
+	for i, reader := range readers {
+		r.readers[i] = NewDocker(r.config, reader.Database, reader.Username, reader.Password).Configure(reader.Docker)
+	}
+
+	return r
+}
+
+// ReaderConfigs returns the configs of the reader containers.
+func (r *Docker) ReaderConfigs() []contractsdocker.DatabaseConfig {
+	configs := make([]contractsdocker.DatabaseConfig, len(r.readers))
+	for i, reader := range r.readers {
+		configs[i] = reader.Config()
+	}
+
+	return configs
+}
+
+// Sync copies the database of the writer to the readers, like a log shipping secondary in standby: the database
+// is backed up, copied into the reader containers and restored read-only, the writes to a reader fail as they do
+// on a readable secondary. Ready and Fresh sync the readers, call it again after writing to the writer to make the
+// changes visible to the readers.
+func (r *Docker) Sync() error {
+	readers := r.builtReaders()
+	if len(readers) == 0 {
+		return nil
+	}
+
+	instance, err := r.connectMaster()
+	if err != nil {
+		return fmt.Errorf("connect Sqlserver error when syncing: %v", err)
+	}
+	defer r.close(instance)
+
+	file := fmt.Sprintf("%s/%s_sync.bak", dataDirectory, r.databaseConfig.Database)
+	if err := instance.Exec(fmt.Sprintf("backup database %s to disk = %s with init, copy_only",
+		quoteIdentifier(r.databaseConfig.Database), quoteString(file))).Error; err != nil {
+		return fmt.Errorf("backup Sqlserver database %s error: %v", r.databaseConfig.Database, err)
+	}
+
+	dir, err := os.MkdirTemp("", "sqlserver-sync")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	local := filepath.Join(dir, filepath.Base(file))
+	if _, err := process.Run(fmt.Sprintf("docker cp %s:%s %s", r.databaseConfig.ContainerID, shellQuote(file), shellQuote(local))); err != nil {
+		return fmt.Errorf("copy backup of Sqlserver error: %v", err)
+	}
+
+	var errs []error
+	for i, reader := range readers {
+		if err := reader.seed(local); err != nil {
+			errs = append(errs, fmt.Errorf("sync Sqlserver reader %d error: %v", i, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// seed restores a backup of the writer into the reader and makes it read-only.
+func (r *Docker) seed(file string) error {
+	target := fmt.Sprintf("%s/%s_seed.bak", dataDirectory, r.databaseConfig.Database)
+	if err := r.copyToContainer(file, target); err != nil {
+		return err
+	}
+
+	instance, err := r.connectMaster()
+	if err != nil {
+		return err
+	}
+	defer r.close(instance)
+
+	if err := r.restoreBak(instance, r.databaseConfig.Database, target); err != nil {
+		return err
+	}
+	if err := r.provisionUsers(instance, r.databaseConfig.Database); err != nil {
+		return err
+	}
+
+	return instance.Exec(fmt.Sprintf("alter database %s set read_only with rollback immediate", quoteIdentifier(r.databaseConfig.Database))).Error
+}
+
+// builtReaders returns the readers whose container is built or reused, the others are not waited for, synced or
+// shut down.
+func (r *Docker) builtReaders() []*Docker {
+	var readers []*Docker
+	for _, reader := range r.readers {
+		if reader.databaseConfig.ContainerID != "" {
+			readers = append(readers, reader)
+		}
+	}
+
+	return readers
+}
+
+// resetReaderPorts updates the ports of the built readers in the config, like resetConfigPort does for the
+// writer. The port of a reader that isn't built is -1, it's kept as it is.
+func (r *Docker) resetReaderPorts() {
+	ports := make([]int, len(r.readers))
+	for i, reader := range r.readers {
+		ports[i] = -1
+		if reader.databaseConfig.ContainerID != "" {
+			ports[i] = reader.databaseConfig.Port
+		}
+	}
+
+	if r.config.Config() == nil {
+		if setter, ok := r.config.(interface{ setReaderPorts([]int) }); ok {
+			setter.setReaderPorts(ports)
+		}
+
+		return
+	}
+
+	key := fmt.Sprintf("database.connections.%s.read", r.config.Connection())
+	if readConfigs, ok := r.config.Config().Get(key).([]contracts.Config); ok {
+		for i := range readConfigs {
+			if i < len(ports) && ports[i] >= 0 {
+				readConfigs[i].Port = ports[i]
+			}
+		}
+		r.config.Config().Add(key, readConfigs)
+	}
+}
@@ -3,12 +3,26 @@
 package sqlserver
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	contractsdocker "github.com/goravel/framework/contracts/testing/docker"
 	"github.com/goravel/framework/mocks/config"
+	"github.com/goravel/framework/support/process"
 	"github.com/goravel/sqlserver/contracts"
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/sqlserver"
+	gormio "gorm.io/gorm"
 )
 
 type DockerTestSuite struct {
@@ -82,6 +96,214 @@ INSERT INTO users (name) VALUES ('goravel');
 	s.Nil(s.docker.Shutdown())
 }
 
+func (s *DockerTestSuite) TestFresh() {
+	s.Nil(s.docker.Build())
+
+	instance, err := s.docker.connect()
+	s.Nil(err)
+
+	for _, statement := range []string{
+		"CREATE SCHEMA crm;",
+		"CREATE TABLE crm.customers (id bigint NOT NULL IDENTITY(1,1) PRIMARY KEY, name varchar(255) NOT NULL);",
+		"CREATE TABLE orders (id bigint NOT NULL IDENTITY(1,1) PRIMARY KEY, customer_id bigint NOT NULL REFERENCES crm.customers (id));",
+		"CREATE VIEW crm.customer_names AS SELECT name FROM crm.customers;",
+		"CREATE VIEW crm.first_customer_names AS SELECT TOP 1 name FROM crm.customer_names;",
+		"CREATE SEQUENCE crm.numbers START WITH 1;",
+		"CREATE TYPE crm.code FROM varchar(10);",
+		"CREATE SYNONYM customers FOR crm.customers;",
+	} {
+		s.Nil(instance.Exec(statement).Error, statement)
+	}
+
+	s.Nil(s.docker.Fresh())
+
+	var count int64
+	s.Nil(instance.Raw("SELECT count(*) FROM sys.objects WHERE is_ms_shipped = 0;").Scan(&count).Error)
+	s.Equal(int64(0), count)
+	s.Nil(instance.Raw("SELECT count(*) FROM sys.schemas WHERE name = 'crm';").Scan(&count).Error)
+	s.Equal(int64(0), count)
+
+	s.Nil(s.docker.Shutdown())
+}
+
+func (s *DockerTestSuite) TestSnapshot() {
+	s.Nil(s.docker.Build())
+
+	instance, err := s.docker.connect()
+	s.Nil(err)
+	s.Nil(instance.Exec("CREATE TABLE users (id bigint NOT NULL IDENTITY(1,1) PRIMARY KEY, name varchar(255) NOT NULL);").Error)
+	s.Nil(instance.Exec("INSERT INTO users (name) VALUES ('goravel');").Error)
+	s.Nil(s.docker.close(instance))
+
+	s.Nil(s.docker.Snapshot("seeded"))
+
+	instance, err = s.docker.connect()
+	s.Nil(err)
+	s.Nil(instance.Exec("INSERT INTO users (name) VALUES ('framework');").Error)
+	s.Nil(s.docker.close(instance))
+
+	s.Nil(s.docker.RestoreSnapshot("seeded"))
+
+	instance, err = s.docker.connect()
+	s.Nil(err)
+	var count int64
+	s.Nil(instance.Raw("SELECT count(*) FROM users;").Scan(&count).Error)
+	s.Equal(int64(1), count)
+	s.Nil(s.docker.close(instance))
+
+	s.Nil(s.docker.Shutdown())
+}
+
+func (s *DockerTestSuite) TestSnapshotSeveral() {
+	s.Nil(s.docker.Build())
+
+	instance, err := s.docker.connect()
+	s.Nil(err)
+	s.Nil(instance.Exec("CREATE TABLE users (id bigint NOT NULL IDENTITY(1,1) PRIMARY KEY, name varchar(255) NOT NULL);").Error)
+	s.Nil(s.docker.close(instance))
+	s.Nil(s.docker.Snapshot("empty"))
+
+	instance, err = s.docker.connect()
+	s.Nil(err)
+	s.Nil(instance.Exec("INSERT INTO users (name) VALUES ('goravel');").Error)
+	s.Nil(s.docker.close(instance))
+	s.Nil(s.docker.Snapshot("seeded"))
+
+	s.Nil(s.docker.RestoreSnapshot("empty"))
+
+	instance, err = s.docker.connect()
+	s.Nil(err)
+	var count int64
+	s.Nil(instance.Raw("SELECT count(*) FROM users;").Scan(&count).Error)
+	s.Equal(int64(0), count)
+	s.Nil(s.docker.close(instance))
+
+	// The other snapshot is dropped by the restore, the restored one is kept.
+	master, err := s.docker.connectMaster()
+	s.Nil(err)
+	var snapshots []string
+	s.Nil(master.Raw("SELECT name FROM sys.databases WHERE source_database_id = db_id(?)", s.docker.databaseConfig.Database).Scan(&snapshots).Error)
+	s.Equal([]string{s.docker.databaseConfig.Database + "_empty"}, snapshots)
+	s.Nil(s.docker.close(master))
+
+	s.Nil(s.docker.RestoreSnapshot("empty"))
+	s.Nil(s.docker.Shutdown())
+}
+
+func TestDropOtherSnapshots(t *testing.T) {
+	assert.Equal(t, "declare @snapshots nvarchar(max) = N''; "+
+		"select @snapshots += N'drop database ' + quotename(name) + N';' from sys.databases "+
+		"where source_database_id = db_id(N'goravel') and name <> N'goravel_it''s'; "+
+		"exec(@snapshots)", dropOtherSnapshots("goravel", "goravel_it's"))
+}
+
+func (s *DockerTestSuite) TestRestoreFixture() {
+	s.Nil(s.docker.Build())
+
+	instance, err := s.docker.connect()
+	s.Nil(err)
+	s.Nil(instance.Exec("CREATE TABLE users (id bigint NOT NULL IDENTITY(1,1) PRIMARY KEY, name varchar(255) NOT NULL);").Error)
+	s.Nil(instance.Exec("INSERT INTO users (name) VALUES ('goravel');").Error)
+	s.Nil(s.docker.close(instance))
+
+	master, err := s.docker.connectMaster()
+	s.Nil(err)
+	s.Nil(master.Exec(fmt.Sprintf("BACKUP DATABASE %s TO DISK = '/tmp/goravel.bak'", s.database)).Error)
+	s.Nil(s.docker.close(master))
+
+	path := filepath.Join(s.T().TempDir(), "goravel.bak")
+	_, err = process.Run(fmt.Sprintf("docker cp %s:/tmp/goravel.bak %s", s.docker.databaseConfig.ContainerID, path))
+	s.Nil(err)
+
+	s.Nil(s.docker.RestoreFixture(Fixture{Path: path, Database: "restored"}))
+
+	docker, err := s.docker.Database("restored")
+	s.Nil(err)
+	instance, err = docker.(*Docker).connect()
+	s.Nil(err)
+	var count int64
+	s.Nil(instance.Raw("SELECT count(*) FROM users;").Scan(&count).Error)
+	s.Equal(int64(1), count)
+
+	s.Nil(s.docker.Shutdown())
+}
+
+func (s *DockerTestSuite) TestClone() {
+	s.Nil(s.docker.Build())
+
+	instance, err := s.docker.connect()
+	s.Nil(err)
+	s.Nil(instance.Exec("CREATE TABLE users (id bigint NOT NULL IDENTITY(1,1) PRIMARY KEY, name varchar(255) NOT NULL);").Error)
+	s.Nil(instance.Exec("INSERT INTO users (name) VALUES ('goravel');").Error)
+	s.Nil(s.docker.close(instance))
+
+	databaseDriver, err := s.docker.AsTemplate().Database("clone")
+	s.Nil(err)
+
+	instance, err = databaseDriver.(*Docker).connect()
+	s.Nil(err)
+	var count int64
+	s.Nil(instance.Raw("SELECT count(*) FROM users;").Scan(&count).Error)
+	s.Equal(int64(1), count)
+	s.Nil(s.docker.close(instance))
+
+	s.Nil(s.docker.DropClones())
+
+	master, err := s.docker.connectMaster()
+	s.Nil(err)
+	s.Nil(master.Raw("SELECT count(*) FROM sys.databases WHERE name = 'clone';").Scan(&count).Error)
+	s.Equal(int64(0), count)
+
+	s.Nil(s.docker.Shutdown())
+}
+
+func (s *DockerTestSuite) TestProvision() {
+	s.docker.Configure(contracts.DockerConfig{
+		Users: []contracts.DockerUser{
+			{Username: "reader", Password: "Reader'123!", Contained: true, Roles: []string{"db_datareader"}, Schema: "reports"},
+		},
+	})
+	s.Nil(s.docker.Build())
+
+	instance, err := s.docker.connect()
+	s.Nil(err)
+	s.Nil(instance.Exec("CREATE TABLE users (id bigint NOT NULL IDENTITY(1,1) PRIMARY KEY, name varchar(255) NOT NULL);").Error)
+	s.Nil(s.docker.close(instance))
+
+	reader, err := gormio.Open(sqlserver.New(sqlserver.Config{
+		DSN: fmt.Sprintf("sqlserver://reader:%s@127.0.0.1:%d?database=%s", url.QueryEscape("Reader'123!"), s.docker.databaseConfig.Port, s.database),
+	}))
+	s.Nil(err)
+	var count int64
+	s.Nil(reader.Raw("SELECT count(*) FROM dbo.users;").Scan(&count).Error)
+	s.Error(reader.Exec("INSERT INTO dbo.users (name) VALUES ('goravel');").Error)
+	s.Nil(s.docker.close(reader))
+
+	s.Nil(s.docker.Shutdown())
+}
+
+func (s *DockerTestSuite) TestFreshKeepsProvisionedSchemas() {
+	s.docker.Configure(contracts.DockerConfig{
+		Users: []contracts.DockerUser{
+			{Username: "migrator", Password: "Migrator!123", Roles: []string{"db_ddladmin"}, Schema: "app"},
+		},
+	})
+	s.Nil(s.docker.Build())
+	s.Nil(s.docker.Fresh())
+
+	migrator, err := gormio.Open(sqlserver.New(sqlserver.Config{
+		DSN: fmt.Sprintf("sqlserver://migrator:%s@127.0.0.1:%d?database=%s", url.QueryEscape("Migrator!123"), s.docker.databaseConfig.Port, s.database),
+	}))
+	s.Nil(err)
+	s.Nil(migrator.Exec("CREATE TABLE items (id bigint NOT NULL PRIMARY KEY);").Error)
+	var schema string
+	s.Nil(migrator.Raw("SELECT schema_name(schema_id) FROM sys.tables WHERE name = 'items';").Scan(&schema).Error)
+	s.Equal("app", schema)
+	s.Nil(s.docker.close(migrator))
+
+	s.Nil(s.docker.Shutdown())
+}
+
 func (s *DockerTestSuite) TestDatabase() {
 	s.Nil(s.docker.Build())
 
@@ -131,3 +353,216 @@ func (s *DockerTestSuite) TestReady() {
 		s.Nil(s.docker.Shutdown())
 	})
 }
+
+func (s *DockerTestSuite) TestReaders() {
+	readers := []contracts.FullConfig{
+		{Config: contracts.Config{Database: s.database, Username: s.username, Password: s.password}},
+	}
+	staticConfig := NewStaticConfig([]contracts.FullConfig{
+		{Config: contracts.Config{Host: "127.0.0.1", Database: s.database, Username: s.username, Password: s.password}},
+	}, readers)
+	docker := NewDocker(staticConfig, s.database, s.username, s.password).Readers(staticConfig.Readers()...)
+	s.Nil(docker.Build())
+	defer func() {
+		s.Nil(docker.Shutdown())
+	}()
+	s.Nil(docker.Ready())
+
+	readerConfig := docker.ReaderConfigs()[0]
+	s.NotEqual(docker.Config().ContainerID, readerConfig.ContainerID)
+	s.Equal(readerConfig.Port, staticConfig.Readers()[0].Port)
+	s.Equal(docker.Config().Port, staticConfig.Writers()[0].Port)
+
+	writer, err := docker.connect()
+	s.Nil(err)
+	s.Nil(writer.Exec("create table users (id int primary key, name nvarchar(255))").Error)
+	s.Nil(writer.Exec("insert into users values (1, N'goravel')").Error)
+	s.Nil(docker.close(writer))
+	s.Nil(docker.Sync())
+
+	reader, err := gormio.Open(sqlserver.New(sqlserver.Config{
+		DSN: fmt.Sprintf("sqlserver://%s:%s@%s:%d?database=%s", s.username, url.QueryEscape(s.password), readerConfig.Host, readerConfig.Port, s.database),
+	}))
+	s.Nil(err)
+	var names []string
+	s.Nil(reader.Raw("select name from users").Scan(&names).Error)
+	s.Equal([]string{"goravel"}, names)
+	s.Error(reader.Exec("insert into users values (2, N'framework')").Error)
+	s.Nil(docker.close(reader))
+}
+
+func TestDockerReaders(t *testing.T) {
+	readers := []contracts.FullConfig{
+		{Config: contracts.Config{Host: "127.0.0.1", Port: 1434, Database: "goravel", Username: "reader", Password: "Framework!123"}},
+		{Config: contracts.Config{Host: "127.0.0.1", Port: 1435, Database: "goravel", Username: "reader", Password: "Framework!123"}},
+	}
+	staticConfig := NewStaticConfig([]contracts.FullConfig{
+		{Config: contracts.Config{Host: "127.0.0.1", Port: 1433, Database: "goravel", Username: "goravel", Password: "Framework!123"}},
+	}, readers)
+
+	driver, err := NewSqlserverWithConfig(staticConfig, nil).Docker()
+	assert.NoError(t, err)
+	docker := driver.(*Docker)
+	configs := docker.ReaderConfigs()
+	assert.Len(t, configs, 2)
+	assert.Equal(t, "reader", configs[1].Username)
+	assert.Equal(t, "goravel", configs[1].Database)
+
+	docker.readers[0].databaseConfig.Port = 14340
+	docker.readers[1].databaseConfig.Port = 14350
+	docker.resetReaderPorts()
+	assert.Equal(t, 1434, staticConfig.Readers()[0].Port)
+	assert.Equal(t, 1435, staticConfig.Readers()[1].Port)
+
+	docker.readers[0].databaseConfig.ContainerID = "reader0"
+	docker.readers[1].databaseConfig.ContainerID = "reader1"
+	docker.resetReaderPorts()
+	assert.Equal(t, 14340, staticConfig.Readers()[0].Port)
+	assert.Equal(t, 14350, staticConfig.Readers()[1].Port)
+
+	mockConfig := config.NewConfig(t)
+	docker = NewDocker(NewConfig(mockConfig, "default"), "goravel", "goravel", "Framework!123").Readers(readers...)
+	docker.readers[0].databaseConfig.Port = 14340
+	docker.readers[0].databaseConfig.ContainerID = "reader0"
+	docker.readers[1].databaseConfig.Port = 14350
+	mockConfig.EXPECT().Get("database.connections.default.read").Return([]contracts.Config{
+		{Host: "127.0.0.1", Port: 1434},
+		{Host: "127.0.0.1", Port: 1435},
+	}).Once()
+	mockConfig.EXPECT().Add("database.connections.default.read", []contracts.Config{
+		{Host: "127.0.0.1", Port: 14340},
+		{Host: "127.0.0.1", Port: 1435},
+	}).Once()
+	docker.resetReaderPorts()
+}
+
+func TestDockerUnbuiltReaders(t *testing.T) {
+	docker := NewDocker(nil, "goravel", "goravel", "Framework!123").Readers(
+		contracts.FullConfig{Config: contracts.Config{Host: "127.0.0.1", Port: 1434}},
+		contracts.FullConfig{Config: contracts.Config{Host: "127.0.0.1", Port: 1435}},
+	)
+	assert.Empty(t, docker.builtReaders())
+	assert.NoError(t, docker.Sync())
+
+	assert.ErrorContains(t, docker.ReuseReader(2, "reader2", 14360), "the Sqlserver reader 2 is not configured")
+	assert.NoError(t, docker.ReuseReader(1, "reader1", 14350))
+	built := docker.builtReaders()
+	assert.Len(t, built, 1)
+	assert.Equal(t, "reader1", built[0].databaseConfig.ContainerID)
+	assert.Equal(t, 14350, built[0].databaseConfig.Port)
+}
+
+func TestRestoreFixtureUnsupported(t *testing.T) {
+	docker := NewDocker(nil, "goravel", "goravel", "Framework!123")
+
+	assert.ErrorContains(t, docker.RestoreFixture(Fixture{Path: "goravel.sql"}), "only .bak and .bacpac are supported")
+}
+
+func TestDockerImage(t *testing.T) {
+	assert.Equal(t, contractsdocker.Image{
+		Repository:   "mcr.microsoft.com/mssql/server",
+		Tag:          "2022-latest",
+		Env:          []string{"ACCEPT_EULA=Y", "MSSQL_SA_PASSWORD=Framework!123"},
+		ExposedPorts: []string{"1433"},
+	}, dockerImage(contracts.DockerConfig{}, "Framework!123"))
+
+	assert.Equal(t, contractsdocker.Image{
+		Repository: "goravel/mssql-fts",
+		Tag:        "2019",
+		Env: []string{
+			"ACCEPT_EULA=Y",
+			"MSSQL_SA_PASSWORD=Framework!123",
+			"MSSQL_PID=Express",
+			"MSSQL_COLLATION=Latin1_General_100_CI_AS_SC_UTF8",
+			"MSSQL_AGENT_ENABLED=true",
+			"MSSQL_MEMORY_LIMIT_MB=2048",
+		},
+		ExposedPorts: []string{"1433"},
+	}, dockerImage(contracts.DockerConfig{
+		Image:       "goravel/mssql-fts",
+		Tag:         "2019",
+		Edition:     "Express",
+		Collation:   "Latin1_General_100_CI_AS_SC_UTF8",
+		Agent:       true,
+		MemoryLimit: 2048,
+		FullText:    true,
+	}, "Framework!123"))
+}
+
+func TestDockerRetry(t *testing.T) {
+	docker := NewDocker(nil, "goravel", "goravel", "Framework!123")
+
+	var calls int
+	assert.NoError(t, docker.retry(context.Background(), func() error {
+		if calls++; calls < 3 {
+			return errors.New("connection refused")
+		}
+
+		return nil
+	}))
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	err := docker.retry(context.Background(), func() error {
+		calls++
+		return mssql.Error{Number: loginFailedErrorNumber, Message: "Login failed for user 'sa'."}
+	})
+	assert.ErrorContains(t, err, "please check the username and password")
+	assert.Equal(t, 1, calls)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 600*time.Millisecond)
+	defer cancel()
+	err = docker.retry(ctx, func() error {
+		return errors.New("connection refused")
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, strings.Count(err.Error(), "connection refused"))
+}
+
+func TestDockerFreshSteps(t *testing.T) {
+	docker := NewDocker(nil, "goravel", "goravel", "Framework!123")
+	assert.Equal(t, freshSteps, docker.freshSteps())
+
+	docker.Configure(contracts.DockerConfig{
+		Schema: "app",
+		Users: []contracts.DockerUser{
+			{Username: "reader", Schema: "reports"},
+			{Username: "writer", Schema: "app"},
+			{Username: "auditor"},
+		},
+	})
+	steps := docker.freshSteps()
+	assert.Len(t, steps, len(freshSteps))
+	assert.Equal(t, "select 'drop schema ' + quotename(name) from sys.schemas where schema_id > 4 and schema_id < 16384 and name not in (N'app', N'reports')",
+		steps[len(steps)-1].query)
+	assert.Equal(t, "select 'drop schema ' + quotename(name) from sys.schemas where schema_id > 4 and schema_id < 16384",
+		freshSteps[len(freshSteps)-1].query)
+}
+
+func TestDockerLogs(t *testing.T) {
+	// A fake docker binary writes the log to stdout and stderr like the server.
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docker"), []byte("#!/bin/sh\necho \"$1 $2 $3 $4\"\necho 'Error: 18456, Severity: 14.' >&2\n"), 0o755))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	docker := NewDocker(nil, "goravel", "goravel", "Framework!123")
+	assert.Empty(t, docker.logs())
+
+	assert.NoError(t, docker.Reuse("goravel", 1433))
+	assert.Equal(t, "logs --tail 50 goravel\nError: 18456, Severity: 14.", docker.logs())
+}
+
+func TestProvisionUserStatements(t *testing.T) {
+	assert.Equal(t, []string{
+		"if suser_id(N'go]ravel') is null create login [go]]ravel] with password = N'Frame''work!123', check_policy = off",
+		"if user_id(N'go]ravel') is null create user [go]]ravel] for login [go]]ravel] else alter user [go]]ravel] with login = [go]]ravel]",
+		"if database_principal_id(N'db_owner') is null create role [db_owner]",
+		"alter role [db_owner] add member [go]]ravel]",
+	}, provisionUserStatements(contracts.DockerUser{Username: "go]ravel", Password: "Frame'work!123", Roles: []string{"db_owner"}}))
+
+	assert.Equal(t, []string{
+		"if user_id(N'reader') is null create user [reader] with password = N'Reader!123'",
+		"if schema_id(N'reports') is null exec(N'create schema [reports]')",
+		"alter user [reader] with default_schema = [reports]",
+	}, provisionUserStatements(contracts.DockerUser{Username: "reader", Password: "Reader!123", Contained: true, Schema: "reports"}))
+}
@@ -0,0 +1,17 @@
+package sqlserver
+
+import (
+	"database/sql"
+
+	"github.com/goravel/sqlserver/contracts"
+)
+
+// StorePool keeps a pool of the connection for the tests of the external package, the pools are removed by
+// ClearPools.
+func StorePool(connection, role string, db *sql.DB) {
+	pools.Store(newPoolKey(contracts.FullConfig{Connection: connection}, role, 0), db)
+}
+
+func ClearPools() {
+	pools.Clear()
+}
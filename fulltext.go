@@ -0,0 +1,131 @@
+package sqlserver
+
+import (
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// FullTextCatalog is the name of the default full-text catalog created by the full-text indexes.
+	FullTextCatalog = "goravel_fulltext"
+
+	// FullTextModeContains matches the CONTAINS search condition, it's the default mode.
+	FullTextModeContains = "contains"
+	// FullTextModeFreeText matches the meaning of the value instead of the exact words.
+	FullTextModeFreeText = "freetext"
+)
+
+// FullText is a full-text search condition, it compiles to CONTAINS or FREETEXT:
+//
+//	query.Where(sqlserver.WhereFullText([]string{"title", "body"}, `"goravel*"`).Language("English"))
+type FullText struct {
+	Columns  []string
+	Value    string
+	language string
+	mode     string
+}
+
+// WhereFullText searches the value in the full-text indexed columns, all the indexed columns are searched if the
+// columns are empty.
+func WhereFullText(columns []string, value string) FullText {
+	return FullText{Columns: columns, Value: value, mode: FullTextModeContains}
+}
+
+// Language sets the language of the word breakers and stemmers, it's a language name or an LCID.
+func (r FullText) Language(language string) FullText {
+	r.language = language
+
+	return r
+}
+
+// Mode sets the search mode, see FullTextModeContains and FullTextModeFreeText.
+func (r FullText) Mode(mode string) FullText {
+	r.mode = mode
+
+	return r
+}
+
+// Rank returns the CONTAINSTABLE or FREETEXTTABLE of the search to order the rows by relevance, it returns the
+// [KEY] and RANK columns. Only the top rows are returned if top is greater than 0:
+//
+//	query.Table("posts").
+//		Joins("join ? as ft on ft.[KEY] = posts.id", sqlserver.WhereFullText([]string{"title"}, "goravel").Rank("posts", 10)).
+//		Order("ft.RANK desc")
+func (r FullText) Rank(table string, top int) FullTextRank {
+	return FullTextRank{FullText: r, Table: table, Top: top}
+}
+
+func (r FullText) Build(builder clause.Builder) {
+	_, _ = builder.WriteString(r.function())
+	_ = builder.WriteByte('(')
+	r.build(builder)
+	_ = builder.WriteByte(')')
+}
+
+func (r FullText) build(builder clause.Builder) {
+	r.writeColumns(builder)
+	_, _ = builder.WriteString(", ")
+	builder.AddVar(builder, r.Value)
+	if r.language != "" {
+		_, _ = builder.WriteString(", language ")
+		_, _ = builder.WriteString(fullTextLanguage(r.language))
+	}
+}
+
+func (r FullText) function() string {
+	if strings.EqualFold(r.mode, FullTextModeFreeText) {
+		return "freetext"
+	}
+
+	return "contains"
+}
+
+func (r FullText) writeColumns(builder clause.Builder) {
+	switch len(r.Columns) {
+	case 0:
+		_ = builder.WriteByte('*')
+	case 1:
+		builder.WriteQuoted(r.Columns[0])
+	default:
+		_ = builder.WriteByte('(')
+		for idx, column := range r.Columns {
+			if idx > 0 {
+				_, _ = builder.WriteString(", ")
+			}
+			builder.WriteQuoted(column)
+		}
+		_ = builder.WriteByte(')')
+	}
+}
+
+// FullTextRank is the CONTAINSTABLE or FREETEXTTABLE of a full-text search, see FullText.Rank.
+type FullTextRank struct {
+	FullText
+	Table string
+	Top   int
+}
+
+func (r FullTextRank) Build(builder clause.Builder) {
+	_, _ = builder.WriteString(r.function())
+	_, _ = builder.WriteString("table(")
+	builder.WriteQuoted(clause.Table{Name: r.Table})
+	_, _ = builder.WriteString(", ")
+	r.build(builder)
+	if r.Top > 0 {
+		_, _ = builder.WriteString(", ")
+		_, _ = builder.WriteString(strconv.Itoa(r.Top))
+	}
+	_ = builder.WriteByte(')')
+}
+
+// fullTextLanguage returns the language term of a full-text index or search, an LCID is kept as it is and a
+// language name is quoted.
+func fullTextLanguage(language string) string {
+	if _, err := strconv.Atoi(language); err == nil {
+		return language
+	}
+
+	return "'" + strings.ReplaceAll(language, "'", "''") + "'"
+}
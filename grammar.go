@@ -20,11 +20,24 @@ import (
 
 var _ driver.Grammar = &Grammar{}
 
+const (
+	// UnicodeNvarchar stores the strings in nvarchar / nchar, it's the default policy.
+	UnicodeNvarchar = "nvarchar"
+	// UnicodeVarchar stores the strings in varchar / char with the code page of the collation.
+	UnicodeVarchar = "varchar"
+	// UnicodeUTF8 stores the strings in varchar / char with a UTF-8 collation, it requires SQL Server 2019+.
+	UnicodeUTF8 = "utf8"
+
+	DefaultUTF8Collation = "Latin1_General_100_CI_AS_SC_UTF8"
+)
+
 type Grammar struct {
 	attributeCommands []string
+	collation         string
 	modifiers         []func(driver.Blueprint, driver.ColumnDefinition) string
 	prefix            string
 	serials           []string
+	unicode           string
 	wrap              *Wrap
 }
 
@@ -44,14 +57,55 @@ func NewGrammar(prefix string) *Grammar {
 	return grammar
 }
 
+// SetUnicode sets the unicode policy of the string columns, see UnicodeNvarchar, UnicodeVarchar and UnicodeUTF8.
+// The collation is applied to the varchar and char columns, DefaultUTF8Collation is used by UnicodeUTF8 if it's empty.
+func (r *Grammar) SetUnicode(unicode, collation string) *Grammar {
+	if unicode == UnicodeUTF8 && collation == "" {
+		collation = DefaultUTF8Collation
+	}
+
+	r.unicode = unicode
+	r.collation = collation
+
+	return r
+}
+
 func (r *Grammar) CompileAdd(blueprint driver.Blueprint, command *driver.Command) string {
 	return fmt.Sprintf("alter table %s add %s", r.wrap.Table(blueprint.GetTableName()), r.getColumn(blueprint, command.Column))
 }
 
+// CompileChange alters the column. A computed column can't be altered, it's dropped with its indexes and added
+// again, the indexes should be created again in the same migration. The current state of the column is checked
+// by the server: a computed column can't be changed to a plain one, and an indexed plain column can't be changed to
+// a computed one, they should be dropped and added again explicitly.
 func (r *Grammar) CompileChange(blueprint driver.Blueprint, command *driver.Command) []string {
+	column, computed := r.compileColumn(blueprint, command.Column)
+	table := r.wrap.Table(blueprint.GetTableName())
+	name := command.Column.GetName()
+	isComputed := fmt.Sprintf("EXISTS (SELECT 1 FROM sys.computed_columns WHERE [object_id] = OBJECT_ID(%s) AND [name] = %s)",
+		r.wrap.Quote(table), r.wrap.Quote(name))
+
+	if computed {
+		isIndexed := fmt.Sprintf("EXISTS (SELECT 1 FROM sys.index_columns AS idxcol "+
+			"JOIN sys.columns AS col ON idxcol.[object_id] = col.[object_id] AND idxcol.[column_id] = col.[column_id] "+
+			"WHERE idxcol.[object_id] = OBJECT_ID(%s) AND col.[name] = %s)", r.wrap.Quote(table), r.wrap.Quote(name))
+
+		return []string{
+			fmt.Sprintf("IF NOT %s AND %s THROW 50000, %s, 1; %s %s alter table %s drop column %s",
+				isComputed, isIndexed,
+				quoteString(fmt.Sprintf("The indexed column %s can't be changed to a computed column, drop its indexes first.", name)),
+				r.CompileDropDefaultConstraint(blueprint, command),
+				r.compileDropIndexes(table, r.wrap.Quote(name)), table, r.wrap.Column(name)),
+			fmt.Sprintf("alter table %s add %s", table, column),
+		}
+	}
+
 	return []string{
-		r.CompileDropDefaultConstraint(blueprint, command),
-		fmt.Sprintf("alter table %s alter column %s", r.wrap.Table(blueprint.GetTableName()), r.getColumn(blueprint, command.Column)),
+		fmt.Sprintf("IF %s THROW 50000, %s, 1; %s",
+			isComputed,
+			quoteString(fmt.Sprintf("The computed column %s can't be changed to a plain column, drop it and add it again.", name)),
+			r.CompileDropDefaultConstraint(blueprint, command)),
+		fmt.Sprintf("alter table %s alter column %s", table, column),
 	}
 }
 
@@ -69,11 +123,11 @@ func (r *Grammar) CompileColumns(_, table string) (string, error) {
 	}
 
 	return fmt.Sprintf(
-		"select col.name, type.name as type_name, "+
+		"select col.name, case when type.name = 'timestamp' then 'rowversion' else type.name end as type_name, "+
 			"col.max_length as length, col.precision as precision, col.scale as places, "+
 			"col.is_nullable as nullable, def.definition as [default], "+
 			"col.is_identity as autoincrement, col.collation_name as collation, "+
-			"com.definition as [expression], is_persisted as [persisted], "+
+			"'as ' + com.definition + case when com.is_persisted = 1 then ' persisted' else '' end as extra, "+
 			"cast(prop.value as nvarchar(max)) as comment "+
 			"from sys.columns as col "+
 			"join sys.types as type on col.user_type_id = type.user_type_id "+
@@ -86,8 +140,15 @@ func (r *Grammar) CompileColumns(_, table string) (string, error) {
 			"order by col.column_id", r.wrap.Quote(table), newSchema), nil
 }
 
-func (r *Grammar) CompileComment(_ driver.Blueprint, _ *driver.Command) string {
-	return ""
+// CompileComment sets the comment of a column as the MS_Description extended property. The comment is
+// removed when it's empty, or when a column is changed without a comment, like the other drivers do.
+func (r *Grammar) CompileComment(blueprint driver.Blueprint, command *driver.Command) string {
+	column := command.Column
+	if !column.IsSetComment() && !column.IsChange() {
+		return ""
+	}
+
+	return r.compileDescription(blueprint, column.GetName(), column.GetComment())
 }
 
 func (r *Grammar) CompileCreate(blueprint driver.Blueprint) string {
@@ -143,13 +204,17 @@ EXEC sp_executesql @sql;`,
 	}
 }
 
+// CompileDropColumn drops the columns with their default constraints, and the indexes containing the computed
+// ones, see compileDropIndexes.
 func (r *Grammar) CompileDropColumn(blueprint driver.Blueprint, command *driver.Command) []string {
 	columns := r.wrap.Columns(command.Columns)
+	table := r.wrap.Table(blueprint.GetTableName())
 
 	dropExistingConstraintsSql := r.CompileDropDefaultConstraint(blueprint, command)
+	dropExistingIndexesSql := r.compileDropIndexes(table, fmt.Sprintf("'%s'", strings.Join(command.Columns, "','")))
 
 	return []string{
-		fmt.Sprintf("%s alter table %s drop column %s", dropExistingConstraintsSql, r.wrap.Table(blueprint.GetTableName()), strings.Join(columns, ", ")),
+		fmt.Sprintf("%s %s alter table %s drop column %s", dropExistingConstraintsSql, dropExistingIndexesSql, table, strings.Join(columns, ", ")),
 	}
 }
 
@@ -174,8 +239,19 @@ func (r *Grammar) CompileDropForeign(blueprint driver.Blueprint, command *driver
 	return fmt.Sprintf("alter table %s drop constraint %s", r.wrap.Table(blueprint.GetTableName()), r.wrap.Column(command.Index))
 }
 
-func (r *Grammar) CompileDropFullText(_ driver.Blueprint, _ *driver.Command) string {
-	return ""
+// CompileDropFullText drops the given columns from the full-text index of the table, the index is dropped when
+// no other column is left or when it's dropped by name. A table has a single full-text index in Sqlserver.
+func (r *Grammar) CompileDropFullText(blueprint driver.Blueprint, command *driver.Command) string {
+	table := r.wrap.Table(blueprint.GetTableName())
+	drop := fmt.Sprintf("drop fulltext index on %s", table)
+	if len(command.Columns) == 0 {
+		return drop
+	}
+
+	return fmt.Sprintf("if exists (select 1 from sys.fulltext_index_columns as fic "+
+		"join sys.columns as col on fic.object_id = col.object_id and fic.column_id = col.column_id "+
+		"where fic.object_id = object_id(%s) and col.name not in (%s)) alter fulltext index on %s drop (%s) else %s",
+		quoteString(table), strings.Join(r.wrap.Quotes(command.Columns), ", "), table, r.wrap.Columnize(command.Columns), drop)
 }
 
 func (r *Grammar) CompileDropIfExists(blueprint driver.Blueprint) string {
@@ -243,16 +319,45 @@ func (r *Grammar) CompileForeignKeys(schema, table string) string {
 	)
 }
 
-func (r *Grammar) CompileFullText(_ driver.Blueprint, _ *driver.Command) string {
-	return ""
-}
+// CompileFullText creates the full-text index of the table, or adds the columns to it when the table has one
+// already. The default full-text catalog is created if it's missing, and the key index is resolved to the primary
+// key or the first unique, single-column, non-nullable index of the table.
+func (r *Grammar) CompileFullText(blueprint driver.Blueprint, command *driver.Command) string {
+	table := r.wrap.Table(blueprint.GetTableName())
+	object := quoteString(table)
+
+	columns := make([]string, len(command.Columns))
+	for i, column := range command.Columns {
+		columns[i] = r.wrap.Column(column)
+		if command.Language != "" {
+			columns[i] += " language " + fullTextLanguage(command.Language)
+		}
+	}
 
+	create := fmt.Sprintf("create fulltext index on %s (%s) key index ", table, strings.Join(columns, ", "))
+
+	return fmt.Sprintf("if exists (select 1 from sys.fulltext_indexes where object_id = object_id(%s)) "+
+		"alter fulltext index on %s add (%s) "+
+		"else begin "+
+		"declare @key sysname = (select top 1 idx.name from sys.indexes as idx "+
+		"join sys.index_columns as idxcol on idx.object_id = idxcol.object_id and idx.index_id = idxcol.index_id and idxcol.is_included_column = 0 "+
+		"join sys.columns as col on idxcol.object_id = col.object_id and idxcol.column_id = col.column_id "+
+		"where idx.object_id = object_id(%s) and idx.is_unique = 1 and idx.is_disabled = 0 and idx.has_filter = 0 "+
+		"group by idx.name, idx.index_id, idx.is_primary_key "+
+		"having count(*) = 1 and max(cast(col.is_nullable as int)) = 0 "+
+		"order by idx.is_primary_key desc, idx.index_id); "+
+		"if @key is null throw 50000, %s, 1; "+
+		"if not exists (select 1 from sys.fulltext_catalogs where is_default = 1) create fulltext catalog %s as default; "+
+		"exec(%s + quotename(@key)); "+
+		"end",
+		object, table, strings.Join(columns, ", "), object,
+		quoteString(fmt.Sprintf("The table %s has no unique, single-column, non-nullable index to be the full-text key index.", table)),
+		quoteIdentifier(FullTextCatalog), quoteString(create))
+}
+
+// CompileIndex creates an index, or a primary key, with the options of the Algorithm, see IndexOptions.
 func (r *Grammar) CompileIndex(blueprint driver.Blueprint, command *driver.Command) string {
-	return fmt.Sprintf("create index %s on %s (%s)",
-		r.wrap.Column(command.Index),
-		r.wrap.Table(blueprint.GetTableName()),
-		r.wrap.Columnize(command.Columns),
-	)
+	return r.compileIndex(blueprint, command, "")
 }
 
 func (r *Grammar) CompileIndexes(_, table string) (string, error) {
@@ -269,15 +374,29 @@ func (r *Grammar) CompileIndexes(_, table string) (string, error) {
 	}
 
 	return fmt.Sprintf(
-		"select idx.name as name, string_agg(col.name, ',') within group (order by idxcol.key_ordinal) as columns, "+
-			"idx.type_desc as [type], idx.is_unique as [unique], idx.is_primary_key as [primary] "+
+		"select idx.name as name, "+
+			"string_agg(case when idx.type <> 5 then col.name + case when idxcol.is_descending_key = 1 then ' desc' else '' end end, ',') "+
+			"within group (order by idxcol.key_ordinal, idxcol.index_column_id) as columns, "+
+			"idx.type_desc"+
+			" + isnull(' include (' + (select string_agg(inccol.name, ', ') within group (order by inc.index_column_id) "+
+			"from sys.index_columns as inc join sys.columns as inccol on inc.object_id = inccol.object_id and inc.column_id = inccol.column_id "+
+			"where inc.object_id = idx.object_id and inc.index_id = idx.index_id and inc.is_included_column = 1 and idx.type not in (5, 6)) + ')', '')"+
+			" + case when idx.has_filter = 1 then ' where ' + idx.filter_definition else '' end"+
+			" + isnull(' with (' + nullif(concat_ws(', ', "+
+			"case when idx.is_padded = 1 then 'pad_index = on' end, "+
+			"case when idx.fill_factor > 0 then 'fillfactor = ' + cast(idx.fill_factor as varchar(3)) end, "+
+			"(select 'data_compression = ' + lower(par.data_compression_desc) from sys.partitions as par "+
+			"where par.object_id = idx.object_id and par.index_id = idx.index_id and par.partition_number = 1 and par.data_compression_desc not in ('NONE', 'COLUMNSTORE'))"+
+			"), '') + ')', '') as [type], "+
+			"idx.is_unique as [unique], idx.is_primary_key as [primary] "+
 			"from sys.indexes as idx "+
 			"join sys.tables as tbl on idx.object_id = tbl.object_id "+
 			"join sys.schemas as scm on tbl.schema_id = scm.schema_id "+
-			"join sys.index_columns as idxcol on idx.object_id = idxcol.object_id and idx.index_id = idxcol.index_id "+
+			"join sys.index_columns as idxcol on idx.object_id = idxcol.object_id and idx.index_id = idxcol.index_id and (idxcol.is_included_column = 0 or idx.type in (5, 6)) "+
 			"join sys.columns as col on idxcol.object_id = col.object_id and idxcol.column_id = col.column_id "+
 			"where tbl.name = %s and scm.name = %s "+
-			"group by idx.name, idx.type_desc, idx.is_unique, idx.is_primary_key",
+			"group by idx.object_id, idx.index_id, idx.name, idx.type_desc, idx.is_unique, idx.is_primary_key, "+
+			"idx.has_filter, idx.filter_definition, idx.is_padded, idx.fill_factor",
 		r.wrap.Quote(table),
 		newSchema,
 	), nil
@@ -477,27 +596,28 @@ func (r *Grammar) CompileSharedLockForGorm() clause.Expression {
 }
 
 func (r *Grammar) CompileTables(_ string) string {
-	return "select t.name as name, schema_name(t.schema_id) as [schema], sum(u.total_pages) * 8 * 1024 as size " +
+	return "select t.name as name, schema_name(t.schema_id) as [schema], sum(u.total_pages) * 8 * 1024 as size, " +
+		"(select cast(prop.value as nvarchar(max)) from sys.extended_properties as prop " +
+		"where prop.class = 1 and prop.major_id = t.object_id and prop.minor_id = 0 and prop.name = 'MS_Description') as comment " +
 		"from sys.tables as t " +
 		"join sys.partitions as p on p.object_id = t.object_id " +
 		"join sys.allocation_units as u on u.container_id = p.hobt_id " +
-		"group by t.name, t.schema_id " +
+		"group by t.name, t.schema_id, t.object_id " +
 		"order by t.name"
 }
 
-func (r *Grammar) CompileTableComment(_ driver.Blueprint, _ *driver.Command) string {
-	return ""
+// CompileTableComment sets the comment of a table as the MS_Description extended property, an empty comment removes it.
+func (r *Grammar) CompileTableComment(blueprint driver.Blueprint, command *driver.Command) string {
+	return r.compileDescription(blueprint, "", command.Value)
 }
 
 func (r *Grammar) CompileTypes() string {
 	return ""
 }
 
+// CompileUnique creates a unique index with the options of the Algorithm, see IndexOptions.
 func (r *Grammar) CompileUnique(blueprint driver.Blueprint, command *driver.Command) string {
-	return fmt.Sprintf("create unique index %s on %s (%s)",
-		r.wrap.Column(command.Index),
-		r.wrap.Table(blueprint.GetTableName()),
-		r.wrap.Columnize(command.Columns))
+	return r.compileIndex(blueprint, command, "unique ")
 }
 
 func (r *Grammar) CompileVersion() string {
@@ -530,12 +650,25 @@ func (r *Grammar) ModifyNullable(_ driver.Blueprint, column driver.ColumnDefinit
 	return " not null"
 }
 
+// ModifyIncrement adds the identity of the auto increment columns, the primary key is added unless the table has
+// one. GeneratedAs sets the seed and the increment, see Identity, and adds an identity to a column that is not
+// the primary key.
 func (r *Grammar) ModifyIncrement(blueprint driver.Blueprint, column driver.ColumnDefinition) string {
-	if !column.IsChange() && slices.Contains(r.serials, column.GetType()) && column.GetAutoIncrement() {
+	if column.IsChange() || !slices.Contains(r.serials, column.GetType()) {
+		return ""
+	}
+
+	if column.GetAutoIncrement() {
+		sql := " identity" + identityOptions(column.GetGeneratedAs())
 		if blueprint.HasCommand("primary") {
-			return " identity"
+			return sql
 		}
-		return " identity primary key"
+
+		return sql + " primary key"
+	}
+
+	if column.IsSetGeneratedAs() {
+		return " identity" + identityOptions(column.GetGeneratedAs())
 	}
 
 	return ""
@@ -545,12 +678,21 @@ func (r *Grammar) TypeBigInteger(_ driver.ColumnDefinition) string {
 	return "bigint"
 }
 
+// TypeBinary is the type of a binary column, its length is 1 unless it's set, see Binary.
+func (r *Grammar) TypeBinary(column driver.ColumnDefinition) string {
+	if column.GetLength() > 0 {
+		return Binary(column.GetLength())
+	}
+
+	return "binary"
+}
+
 func (r *Grammar) TypeBoolean(_ driver.ColumnDefinition) string {
 	return "bit"
 }
 
 func (r *Grammar) TypeChar(column driver.ColumnDefinition) string {
-	return fmt.Sprintf("nchar(%d)", column.GetLength())
+	return r.stringType("char", strconv.Itoa(column.GetLength()))
 }
 
 func (r *Grammar) TypeDate(_ driver.ColumnDefinition) string {
@@ -565,6 +707,19 @@ func (r *Grammar) TypeDateTimeTz(column driver.ColumnDefinition) string {
 	return r.TypeTimestampTz(column)
 }
 
+// TypeDateTime2 is the type of a datetime2 column, its precision is 7 unless it's set, see DateTime2.
+func (r *Grammar) TypeDateTime2(column driver.ColumnDefinition) string {
+	if column.GetUseCurrent() {
+		column.Default(schema.Expression("CURRENT_TIMESTAMP"))
+	}
+
+	if column.GetPrecision() > 0 {
+		return DateTime2(column.GetPrecision())
+	}
+
+	return "datetime2"
+}
+
 func (r *Grammar) TypeDecimal(column driver.ColumnDefinition) string {
 	return fmt.Sprintf("decimal(%d, %d)", column.GetTotal(), column.GetPlaces())
 }
@@ -574,7 +729,14 @@ func (r *Grammar) TypeDouble(_ driver.ColumnDefinition) string {
 }
 
 func (r *Grammar) TypeEnum(column driver.ColumnDefinition) string {
-	return fmt.Sprintf(`nvarchar(255) check ("%s" in (%s))`, column.GetName(), strings.Join(r.wrap.Quotes(cast.ToStringSlice(column.GetAllowed())), ", "))
+	allowed := cast.ToStringSlice(column.GetAllowed())
+	if r.isNonUnicode() {
+		allowed = r.wrap.Wrap.Quotes(allowed)
+	} else {
+		allowed = r.wrap.Quotes(allowed)
+	}
+
+	return fmt.Sprintf(`%s check ("%s" in (%s))`, r.stringType("varchar", "255"), column.GetName(), strings.Join(allowed, ", "))
 }
 
 func (r *Grammar) TypeFloat(column driver.ColumnDefinition) string {
@@ -586,6 +748,19 @@ func (r *Grammar) TypeFloat(column driver.ColumnDefinition) string {
 	return "float"
 }
 
+func (r *Grammar) TypeGeography(_ driver.ColumnDefinition) string {
+	return "geography"
+}
+
+func (r *Grammar) TypeGeometry(_ driver.ColumnDefinition) string {
+	return "geometry"
+}
+
+// TypeHierarchyId is the type of a column storing a position in a tree.
+func (r *Grammar) TypeHierarchyId(_ driver.ColumnDefinition) string {
+	return "hierarchyid"
+}
+
 func (r *Grammar) TypeInteger(_ driver.ColumnDefinition) string {
 	return "int"
 }
@@ -599,7 +774,7 @@ func (r *Grammar) TypeJsonb(_ driver.ColumnDefinition) string {
 }
 
 func (r *Grammar) TypeLongText(_ driver.ColumnDefinition) string {
-	return "nvarchar(max)"
+	return r.stringType("varchar", "max")
 }
 
 func (r *Grammar) TypeMediumInteger(_ driver.ColumnDefinition) string {
@@ -607,24 +782,59 @@ func (r *Grammar) TypeMediumInteger(_ driver.ColumnDefinition) string {
 }
 
 func (r *Grammar) TypeMediumText(_ driver.ColumnDefinition) string {
-	return "nvarchar(max)"
+	return r.stringType("varchar", "max")
+}
+
+func (r *Grammar) TypeMoney(_ driver.ColumnDefinition) string {
+	return "money"
+}
+
+// TypeRowVersion is the type of a column set to a unique binary number by each insert and update of the row, a
+// table has at most one.
+func (r *Grammar) TypeRowVersion(_ driver.ColumnDefinition) string {
+	return "rowversion"
+}
+
+// TypeSequentialUuid is the type of a uniqueidentifier column defaulting to NewSequentialID.
+func (r *Grammar) TypeSequentialUuid(column driver.ColumnDefinition) string {
+	column.Default(schema.Expression(NewSequentialID))
+
+	return "uniqueidentifier"
+}
+
+// TypeSmallDateTime is the type of a smalldatetime column, it's accurate to the minute.
+func (r *Grammar) TypeSmallDateTime(column driver.ColumnDefinition) string {
+	if column.GetUseCurrent() {
+		column.Default(schema.Expression("CURRENT_TIMESTAMP"))
+	}
+
+	return "smalldatetime"
 }
 
 func (r *Grammar) TypeSmallInteger(_ driver.ColumnDefinition) string {
 	return "smallint"
 }
 
+func (r *Grammar) TypeSmallMoney(_ driver.ColumnDefinition) string {
+	return "smallmoney"
+}
+
+// TypeSqlVariant is the type of a column storing the values of the other base types.
+func (r *Grammar) TypeSqlVariant(_ driver.ColumnDefinition) string {
+	return "sql_variant"
+}
+
 func (r *Grammar) TypeString(column driver.ColumnDefinition) string {
 	length := column.GetLength()
 	if length > 0 {
-		return fmt.Sprintf("nvarchar(%d)", length)
+		return r.stringType("varchar", strconv.Itoa(length))
 	}
 
-	return "nvarchar(255)"
+	return r.stringType("varchar", "255")
 }
 
 func (r *Grammar) TypeText(_ driver.ColumnDefinition) string {
-	return "nvarchar(max)"
+	return r.stringType("varchar", "max")
 }
 
 func (r *Grammar) TypeTime(column driver.ColumnDefinition) string {
@@ -668,13 +878,18 @@ func (r *Grammar) TypeTinyInteger(_ driver.ColumnDefinition) string {
 }
 
 func (r *Grammar) TypeTinyText(_ driver.ColumnDefinition) string {
-	return "nvarchar(255)"
+	return r.stringType("varchar", "255")
 }
 
 func (r *Grammar) TypeUuid(_ driver.ColumnDefinition) string {
 	return "uniqueidentifier"
 }
 
+// TypeVarBinary is the type of a varbinary column, its length is max unless it's set, see VarBinary.
+func (r *Grammar) TypeVarBinary(column driver.ColumnDefinition) string {
+	return VarBinary(column.GetLength())
+}
+
 func (r *Grammar) compileDecimalCastExpr(value float64) (string, string) {
 	param := strconv.FormatFloat(value, 'f', -1, 64)
 	parts := strings.Split(param, ".")
@@ -693,6 +908,115 @@ func (r *Grammar) compileDecimalCastExpr(value float64) (string, string) {
 	return fmt.Sprintf("cast(? as decimal(%d,%d))", precision, decLen), param
 }
 
+// compileDescription adds, updates or drops the MS_Description extended property of a table, or of a column when
+// the column is not empty. The schema of the table defaults to the default schema of the user.
+func (r *Grammar) compileDescription(blueprint driver.Blueprint, column, comment string) string {
+	tableName := blueprint.GetTableName()
+	schema, table, err := parseSchemaAndTable(tableName, "")
+	if err != nil {
+		return ""
+	}
+
+	schemaName := "schema_name()"
+	if schema != "" {
+		schemaName = quoteString(schema)
+	}
+
+	object := quoteString(r.wrap.Table(tableName))
+	target := fmt.Sprintf("N'SCHEMA', @schema, N'TABLE', %s", quoteString(r.prefix+table))
+	minorID := "0"
+	if column != "" {
+		target += fmt.Sprintf(", N'COLUMN', %s", quoteString(column))
+		minorID = fmt.Sprintf("columnproperty(object_id(%s), %s, 'ColumnId')", object, quoteString(column))
+	}
+
+	sql := fmt.Sprintf("declare @schema sysname = %s; "+
+		"if exists (select 1 from sys.extended_properties where class = 1 and major_id = object_id(%s) and minor_id = %s and name = N'MS_Description') ",
+		schemaName, object, minorID)
+	if comment == "" {
+		return sql + "exec sp_dropextendedproperty N'MS_Description', " + target
+	}
+
+	value := quoteString(comment)
+
+	return sql + fmt.Sprintf("exec sp_updateextendedproperty N'MS_Description', %s, %s else exec sp_addextendedproperty N'MS_Description', %s, %s",
+		value, target, value, target)
+}
+
+func (r *Grammar) compileIndex(blueprint driver.Blueprint, command *driver.Command, unique string) string {
+	algorithm := parseIndexAlgorithm(command.Algorithm)
+	kind := ""
+	if algorithm.kind != "" {
+		kind = algorithm.kind + " "
+	}
+	clauses := ""
+	if len(algorithm.include) > 0 {
+		clauses += fmt.Sprintf(" include (%s)", r.wrap.Columnize(algorithm.include))
+	}
+	if len(algorithm.order) > 0 {
+		clauses += fmt.Sprintf(" order (%s)", r.wrap.Columnize(algorithm.order))
+	}
+	if algorithm.clauses != "" {
+		clauses += " " + algorithm.clauses
+	}
+
+	if algorithm.rebuild {
+		return fmt.Sprintf("alter index %s on %s rebuild%s", r.wrap.Column(command.Index), r.wrap.Table(blueprint.GetTableName()), clauses)
+	}
+
+	if algorithm.primary {
+		return fmt.Sprintf("alter table %s add constraint %s primary key %s(%s)%s",
+			r.wrap.Table(blueprint.GetTableName()),
+			r.wrap.Column(command.Index),
+			kind,
+			r.indexColumns(command.Columns),
+			clauses)
+	}
+
+	columns := ""
+	if !algorithm.columnstore() || len(command.Columns) > 0 {
+		columns = fmt.Sprintf(" (%s)", r.indexColumns(command.Columns))
+	}
+
+	return fmt.Sprintf("create %s%sindex %s on %s%s%s",
+		unique,
+		kind,
+		r.wrap.Column(command.Index),
+		r.wrap.Table(blueprint.GetTableName()),
+		columns,
+		clauses)
+}
+
+// indexColumns wraps the key columns of an index, a column with a " desc" or " asc" suffix is sorted accordingly.
+func (r *Grammar) indexColumns(columns []string) string {
+	wrapped := make([]string, len(columns))
+	for i, column := range columns {
+		name, order := column, ""
+		if index := strings.LastIndex(column, " "); index > 0 {
+			if suffix := strings.ToLower(column[index+1:]); suffix == "asc" || suffix == "desc" {
+				name, order = column[:index], " "+suffix
+			}
+		}
+		wrapped[i] = r.wrap.Column(name) + order
+	}
+
+	return strings.Join(wrapped, ", ")
+}
+
+// compileDropIndexes drops the indexes containing the computed columns among the columns, a computed column can't be
+// dropped while an index depends on it. The indexes of the other columns, the primary keys and the unique
+// constraints are kept, so SQL Server refuses to drop a column they depend on.
+func (r *Grammar) compileDropIndexes(table, columns string) string {
+	return fmt.Sprintf("DECLARE @indexes NVARCHAR(MAX) = '';"+
+		"SELECT @indexes += 'DROP INDEX ' + QUOTENAME([name]) + ' ON %s;' "+
+		"FROM sys.indexes AS idx "+
+		"WHERE [object_id] = OBJECT_ID(%s) AND [is_primary_key] = 0 AND [is_unique_constraint] = 0 "+
+		"AND EXISTS (SELECT 1 FROM sys.index_columns AS idxcol "+
+		"JOIN sys.computed_columns AS col ON idxcol.[object_id] = col.[object_id] AND idxcol.[column_id] = col.[column_id] "+
+		"WHERE idxcol.[object_id] = idx.[object_id] AND idxcol.[index_id] = idx.[index_id] AND col.[name] in (%s));"+
+		"EXEC(@indexes);", table, r.wrap.Quote(table), columns)
+}
+
 func (r *Grammar) getColumns(blueprint driver.Blueprint) []string {
 	var columns []string
 	for _, column := range blueprint.GetAddedColumns() {
@@ -703,12 +1027,42 @@ func (r *Grammar) getColumns(blueprint driver.Blueprint) []string {
 }
 
 func (r *Grammar) getColumn(blueprint driver.Blueprint, column driver.ColumnDefinition) string {
-	sql := fmt.Sprintf("%s %s", r.wrap.Column(column.GetName()), schema.ColumnType(r, column))
+	sql, _ := r.compileColumn(blueprint, column)
+
+	return sql
+}
+
+// compileColumn returns the definition of the column and whether it's a computed column, the type and the
+// modifiers of a computed column are skipped, see StoredAs and VirtualAs.
+func (r *Grammar) compileColumn(blueprint driver.Blueprint, column driver.ColumnDefinition) (string, bool) {
+	columnType := schema.ColumnType(r, column)
+	sql := fmt.Sprintf("%s %s", r.wrap.Column(column.GetName()), columnType)
+	if isComputed(columnType) {
+		return sql, true
+	}
 
 	for _, modifier := range r.modifiers {
 		sql += modifier(blueprint, column)
 	}
 
+	return sql, false
+}
+
+func (r *Grammar) isNonUnicode() bool {
+	return r.unicode == UnicodeVarchar || r.unicode == UnicodeUTF8
+}
+
+// stringType returns the varchar or char type according to the unicode policy.
+func (r *Grammar) stringType(typeName, length string) string {
+	if !r.isNonUnicode() {
+		return fmt.Sprintf("n%s(%s)", typeName, length)
+	}
+
+	sql := fmt.Sprintf("%s(%s)", typeName, length)
+	if r.collation != "" {
+		sql += " collate " + r.collation
+	}
+
 	return sql
 }
 
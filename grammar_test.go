@@ -1,6 +1,7 @@
 package sqlserver
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/goravel/framework/contracts/database/driver"
@@ -46,12 +47,29 @@ func (s *GrammarSuite) TestCompileAdd() {
 	s.Equal(`alter table "goravel_users" add "name" nvarchar(1) default 'goravel' not null`, sql)
 }
 
+func (s *GrammarSuite) TestCompileAddComputed() {
+	mockBlueprint := mocksdriver.NewBlueprint(s.T())
+	mockColumn := mocksdriver.NewColumnDefinition(s.T())
+
+	mockBlueprint.EXPECT().GetTableName().Return("orders").Twice()
+	mockColumn.EXPECT().GetName().Return("total").Twice()
+	mockColumn.EXPECT().GetType().Return(VirtualAs("[price] * [quantity]")).Twice()
+	mockColumn.EXPECT().GetType().Return(StoredAs("[price] * [quantity]")).Twice()
+
+	s.Equal(`alter table "goravel_orders" add "total" as ([price] * [quantity])`, s.grammar.CompileAdd(mockBlueprint, &driver.Command{
+		Column: mockColumn,
+	}))
+	s.Equal(`alter table "goravel_orders" add "total" as ([price] * [quantity]) persisted`, s.grammar.CompileAdd(mockBlueprint, &driver.Command{
+		Column: mockColumn,
+	}))
+}
+
 func (s *GrammarSuite) TestCompileChange() {
 	mockBlueprint := mocksdriver.NewBlueprint(s.T())
 	mockColumn := mocksdriver.NewColumnDefinition(s.T())
 
 	mockBlueprint.EXPECT().GetTableName().Return("users").Twice()
-	mockColumn.EXPECT().GetName().Return("name").Twice()
+	mockColumn.EXPECT().GetName().Return("name").Times(3)
 	mockColumn.EXPECT().GetType().Return("string").Once()
 	mockColumn.EXPECT().GetNullable().Return(false).Once()
 	mockColumn.EXPECT().GetLength().Return(1).Once()
@@ -61,12 +79,44 @@ func (s *GrammarSuite) TestCompileChange() {
 		Column: mockColumn,
 	})
 
+	// A computed column is rejected, it can't be altered to a plain one.
 	s.Equal([]string{
-		`DECLARE @sql NVARCHAR(MAX) = '';SELECT @sql += 'ALTER TABLE "goravel_users" DROP CONSTRAINT ' + OBJECT_NAME([default_object_id]) + ';' FROM sys.columns WHERE [object_id] = OBJECT_ID('"goravel_users"') AND [name] in ('name') AND [default_object_id] <> 0;EXEC(@sql);`,
+		`IF EXISTS (SELECT 1 FROM sys.computed_columns WHERE [object_id] = OBJECT_ID('"goravel_users"') AND [name] = 'name') ` +
+			`THROW 50000, N'The computed column name can''t be changed to a plain column, drop it and add it again.', 1; ` +
+			`DECLARE @sql NVARCHAR(MAX) = '';SELECT @sql += 'ALTER TABLE "goravel_users" DROP CONSTRAINT ' + OBJECT_NAME([default_object_id]) + ';' FROM sys.columns WHERE [object_id] = OBJECT_ID('"goravel_users"') AND [name] in ('name') AND [default_object_id] <> 0;EXEC(@sql);`,
 		`alter table "goravel_users" alter column "name" nvarchar(1) not null`,
 	}, sql)
 }
 
+func (s *GrammarSuite) TestCompileChangeComputed() {
+	mockBlueprint := mocksdriver.NewBlueprint(s.T())
+	mockColumn := mocksdriver.NewColumnDefinition(s.T())
+
+	mockBlueprint.EXPECT().GetTableName().Return("orders").Twice()
+	mockColumn.EXPECT().GetName().Return("total").Times(3)
+	mockColumn.EXPECT().GetType().Return(StoredAs("[price] * [quantity]")).Twice()
+	mockColumn.EXPECT().IsChange().Return(true).Once()
+
+	// A plain column is dropped with its default and added again, unless it's indexed.
+	s.Equal([]string{
+		`IF NOT EXISTS (SELECT 1 FROM sys.computed_columns WHERE [object_id] = OBJECT_ID('"goravel_orders"') AND [name] = 'total') ` +
+			`AND EXISTS (SELECT 1 FROM sys.index_columns AS idxcol ` +
+			`JOIN sys.columns AS col ON idxcol.[object_id] = col.[object_id] AND idxcol.[column_id] = col.[column_id] ` +
+			`WHERE idxcol.[object_id] = OBJECT_ID('"goravel_orders"') AND col.[name] = 'total') ` +
+			`THROW 50000, N'The indexed column total can''t be changed to a computed column, drop its indexes first.', 1; ` +
+			`DECLARE @sql NVARCHAR(MAX) = '';SELECT @sql += 'ALTER TABLE "goravel_orders" DROP CONSTRAINT ' + OBJECT_NAME([default_object_id]) + ';' FROM sys.columns WHERE [object_id] = OBJECT_ID('"goravel_orders"') AND [name] in ('total') AND [default_object_id] <> 0;EXEC(@sql); ` +
+			`DECLARE @indexes NVARCHAR(MAX) = '';SELECT @indexes += 'DROP INDEX ' + QUOTENAME([name]) + ' ON "goravel_orders";' ` +
+			`FROM sys.indexes AS idx WHERE [object_id] = OBJECT_ID('"goravel_orders"') AND [is_primary_key] = 0 AND [is_unique_constraint] = 0 ` +
+			`AND EXISTS (SELECT 1 FROM sys.index_columns AS idxcol ` +
+			`JOIN sys.computed_columns AS col ON idxcol.[object_id] = col.[object_id] AND idxcol.[column_id] = col.[column_id] ` +
+			`WHERE idxcol.[object_id] = idx.[object_id] AND idxcol.[index_id] = idx.[index_id] AND col.[name] in ('total'));EXEC(@indexes); ` +
+			`alter table "goravel_orders" drop column "total"`,
+		`alter table "goravel_orders" add "total" as ([price] * [quantity]) persisted`,
+	}, s.grammar.CompileChange(mockBlueprint, &driver.Command{
+		Column: mockColumn,
+	}))
+}
+
 func (s *GrammarSuite) TestCompileColumns() {
 	tests := []struct {
 		name          string
@@ -77,11 +127,11 @@ func (s *GrammarSuite) TestCompileColumns() {
 		{
 			name:  "with schema",
 			table: "users",
-			expectedSQL: `select col.name, type.name as type_name, ` +
+			expectedSQL: `select col.name, case when type.name = 'timestamp' then 'rowversion' else type.name end as type_name, ` +
 				`col.max_length as length, col.precision as precision, col.scale as places, ` +
 				`col.is_nullable as nullable, def.definition as [default], ` +
 				`col.is_identity as autoincrement, col.collation_name as collation, ` +
-				`com.definition as [expression], is_persisted as [persisted], ` +
+				`'as ' + com.definition + case when com.is_persisted = 1 then ' persisted' else '' end as extra, ` +
 				`cast(prop.value as nvarchar(max)) as comment ` +
 				`from sys.columns as col ` +
 				`join sys.types as type on col.user_type_id = type.user_type_id ` +
@@ -97,11 +147,11 @@ func (s *GrammarSuite) TestCompileColumns() {
 		{
 			name:  "without schema",
 			table: "users",
-			expectedSQL: `select col.name, type.name as type_name, ` +
+			expectedSQL: `select col.name, case when type.name = 'timestamp' then 'rowversion' else type.name end as type_name, ` +
 				`col.max_length as length, col.precision as precision, col.scale as places, ` +
 				`col.is_nullable as nullable, def.definition as [default], ` +
 				`col.is_identity as autoincrement, col.collation_name as collation, ` +
-				`com.definition as [expression], is_persisted as [persisted], ` +
+				`'as ' + com.definition + case when com.is_persisted = 1 then ' persisted' else '' end as extra, ` +
 				`cast(prop.value as nvarchar(max)) as comment ` +
 				`from sys.columns as col ` +
 				`join sys.types as type on col.user_type_id = type.user_type_id ` +
@@ -137,6 +187,49 @@ func (s *GrammarSuite) TestCompileColumns() {
 	}
 }
 
+func (s *GrammarSuite) TestCompileComment() {
+	mockBlueprint := mocksdriver.NewBlueprint(s.T())
+	mockColumn := mocksdriver.NewColumnDefinition(s.T())
+
+	mockColumn.EXPECT().IsSetComment().Return(true).Once()
+	mockColumn.EXPECT().GetName().Return("name").Once()
+	mockColumn.EXPECT().GetComment().Return("It's the name").Once()
+	mockBlueprint.EXPECT().GetTableName().Return("sales.users").Once()
+
+	s.Equal("declare @schema sysname = N'sales'; "+
+		`if exists (select 1 from sys.extended_properties where class = 1 and major_id = object_id(N'"sales"."goravel_users"') and minor_id = columnproperty(object_id(N'"sales"."goravel_users"'), N'name', 'ColumnId') and name = N'MS_Description') `+
+		"exec sp_updateextendedproperty N'MS_Description', N'It''s the name', N'SCHEMA', @schema, N'TABLE', N'goravel_users', N'COLUMN', N'name' "+
+		"else exec sp_addextendedproperty N'MS_Description', N'It''s the name', N'SCHEMA', @schema, N'TABLE', N'goravel_users', N'COLUMN', N'name'",
+		s.grammar.CompileComment(mockBlueprint, &driver.Command{Column: mockColumn}))
+
+	// A changed column without a comment loses its comment.
+	mockColumn.EXPECT().IsSetComment().Return(false).Once()
+	mockColumn.EXPECT().IsChange().Return(true).Once()
+	mockColumn.EXPECT().GetName().Return("name").Once()
+	mockColumn.EXPECT().GetComment().Return("").Once()
+	mockBlueprint.EXPECT().GetTableName().Return("users").Once()
+
+	s.Equal("declare @schema sysname = schema_name(); "+
+		`if exists (select 1 from sys.extended_properties where class = 1 and major_id = object_id(N'"goravel_users"') and minor_id = columnproperty(object_id(N'"goravel_users"'), N'name', 'ColumnId') and name = N'MS_Description') `+
+		"exec sp_dropextendedproperty N'MS_Description', N'SCHEMA', @schema, N'TABLE', N'goravel_users', N'COLUMN', N'name'",
+		s.grammar.CompileComment(mockBlueprint, &driver.Command{Column: mockColumn}))
+
+	mockColumn.EXPECT().IsSetComment().Return(false).Once()
+	mockColumn.EXPECT().IsChange().Return(false).Once()
+	s.Empty(s.grammar.CompileComment(mockBlueprint, &driver.Command{Column: mockColumn}))
+}
+
+func (s *GrammarSuite) TestCompileTableComment() {
+	mockBlueprint := mocksdriver.NewBlueprint(s.T())
+	mockBlueprint.EXPECT().GetTableName().Return("users").Once()
+
+	s.Equal("declare @schema sysname = schema_name(); "+
+		`if exists (select 1 from sys.extended_properties where class = 1 and major_id = object_id(N'"goravel_users"') and minor_id = 0 and name = N'MS_Description') `+
+		"exec sp_updateextendedproperty N'MS_Description', N'The users', N'SCHEMA', @schema, N'TABLE', N'goravel_users' "+
+		"else exec sp_addextendedproperty N'MS_Description', N'The users', N'SCHEMA', @schema, N'TABLE', N'goravel_users'",
+		s.grammar.CompileTableComment(mockBlueprint, &driver.Command{Value: "The users"}))
+}
+
 func (s *GrammarSuite) TestCompileCreate() {
 	mockColumn1 := mocksdriver.NewColumnDefinition(s.T())
 	mockColumn2 := mocksdriver.NewColumnDefinition(s.T())
@@ -154,6 +247,7 @@ func (s *GrammarSuite) TestCompileCreate() {
 	mockColumn1.EXPECT().GetType().Return("integer").Once()
 	// grammar.go::TypeInteger
 	mockColumn1.EXPECT().GetAutoIncrement().Return(true).Once()
+	mockColumn1.EXPECT().GetGeneratedAs().Return("").Once()
 	// grammar.go::ModifyDefault
 	mockColumn1.EXPECT().GetDefault().Return(nil).Once()
 	// grammar.go::ModifyIncrement
@@ -201,11 +295,32 @@ func (s *GrammarSuite) TestCompileDropColumn() {
 	mockBlueprint := mocksdriver.NewBlueprint(s.T())
 	mockBlueprint.EXPECT().GetTableName().Return("users").Twice()
 
-	s.Equal([]string{`DECLARE @sql NVARCHAR(MAX) = '';SELECT @sql += 'ALTER TABLE "goravel_users" DROP CONSTRAINT ' + OBJECT_NAME([default_object_id]) + ';' FROM sys.columns WHERE [object_id] = OBJECT_ID('"goravel_users"') AND [name] in ('id','name') AND [default_object_id] <> 0;EXEC(@sql); alter table "goravel_users" drop column "id", "name"`}, s.grammar.CompileDropColumn(mockBlueprint, &driver.Command{
+	s.Equal([]string{`DECLARE @sql NVARCHAR(MAX) = '';SELECT @sql += 'ALTER TABLE "goravel_users" DROP CONSTRAINT ' + OBJECT_NAME([default_object_id]) + ';' FROM sys.columns WHERE [object_id] = OBJECT_ID('"goravel_users"') AND [name] in ('id','name') AND [default_object_id] <> 0;EXEC(@sql); ` +
+		`DECLARE @indexes NVARCHAR(MAX) = '';SELECT @indexes += 'DROP INDEX ' + QUOTENAME([name]) + ' ON "goravel_users";' ` +
+		`FROM sys.indexes AS idx WHERE [object_id] = OBJECT_ID('"goravel_users"') AND [is_primary_key] = 0 AND [is_unique_constraint] = 0 ` +
+		`AND EXISTS (SELECT 1 FROM sys.index_columns AS idxcol ` +
+		`JOIN sys.computed_columns AS col ON idxcol.[object_id] = col.[object_id] AND idxcol.[column_id] = col.[column_id] ` +
+		`WHERE idxcol.[object_id] = idx.[object_id] AND idxcol.[index_id] = idx.[index_id] AND col.[name] in ('id','name'));EXEC(@indexes); ` +
+		`alter table "goravel_users" drop column "id", "name"`}, s.grammar.CompileDropColumn(mockBlueprint, &driver.Command{
 		Columns: []string{"id", "name"},
 	}))
 }
 
+func (s *GrammarSuite) TestCompileDropColumnKeepsIndexes() {
+	mockBlueprint := mocksdriver.NewBlueprint(s.T())
+	mockBlueprint.EXPECT().GetTableName().Return("users").Twice()
+
+	sql := s.grammar.CompileDropColumn(mockBlueprint, &driver.Command{Columns: []string{"email"}})[0]
+
+	// Only the indexes of the computed columns are dropped, the indexes and the constraints of a plain column make
+	// SQL Server refuse the drop.
+	s.Contains(sql, "JOIN sys.computed_columns AS col")
+	s.NotContains(sql, "JOIN sys.columns AS col")
+	s.Contains(sql, "[is_primary_key] = 0 AND [is_unique_constraint] = 0")
+	s.Equal(1, strings.Count(sql, "DROP CONSTRAINT"))
+	s.Contains(sql, "OBJECT_NAME([default_object_id])")
+}
+
 func (s *GrammarSuite) TestCompileDropIfExists() {
 	mockBlueprint := mocksdriver.NewBlueprint(s.T())
 	mockBlueprint.EXPECT().GetTableName().Return("users").Once()
@@ -260,6 +375,48 @@ func (s *GrammarSuite) TestCompileForeign() {
 	}
 }
 
+func (s *GrammarSuite) TestCompileFullText() {
+	mockBlueprint := mocksdriver.NewBlueprint(s.T())
+	mockBlueprint.EXPECT().GetTableName().Return("posts").Once()
+
+	s.Equal(`if exists (select 1 from sys.fulltext_indexes where object_id = object_id(N'"goravel_posts"')) `+
+		`alter fulltext index on "goravel_posts" add ("title" language 'English', "body" language 'English') `+
+		"else begin "+
+		"declare @key sysname = (select top 1 idx.name from sys.indexes as idx "+
+		"join sys.index_columns as idxcol on idx.object_id = idxcol.object_id and idx.index_id = idxcol.index_id and idxcol.is_included_column = 0 "+
+		"join sys.columns as col on idxcol.object_id = col.object_id and idxcol.column_id = col.column_id "+
+		`where idx.object_id = object_id(N'"goravel_posts"') and idx.is_unique = 1 and idx.is_disabled = 0 and idx.has_filter = 0 `+
+		"group by idx.name, idx.index_id, idx.is_primary_key "+
+		"having count(*) = 1 and max(cast(col.is_nullable as int)) = 0 "+
+		"order by idx.is_primary_key desc, idx.index_id); "+
+		`if @key is null throw 50000, N'The table "goravel_posts" has no unique, single-column, non-nullable index to be the full-text key index.', 1; `+
+		"if not exists (select 1 from sys.fulltext_catalogs where is_default = 1) create fulltext catalog [goravel_fulltext] as default; "+
+		`exec(N'create fulltext index on "goravel_posts" ("title" language ''English'', "body" language ''English'') key index ' + quotename(@key)); `+
+		"end",
+		s.grammar.CompileFullText(mockBlueprint, &driver.Command{
+			Index:    "goravel_posts_title_body_fulltext",
+			Columns:  []string{"title", "body"},
+			Language: "English",
+		}))
+}
+
+func (s *GrammarSuite) TestCompileDropFullText() {
+	mockBlueprint := mocksdriver.NewBlueprint(s.T())
+	mockBlueprint.EXPECT().GetTableName().Return("posts").Twice()
+
+	s.Equal(`drop fulltext index on "goravel_posts"`, s.grammar.CompileDropFullText(mockBlueprint, &driver.Command{
+		Index: "goravel_posts_title_fulltext",
+	}))
+	s.Equal("if exists (select 1 from sys.fulltext_index_columns as fic "+
+		"join sys.columns as col on fic.object_id = col.object_id and fic.column_id = col.column_id "+
+		`where fic.object_id = object_id(N'"goravel_posts"') and col.name not in (N'title', N'body')) `+
+		`alter fulltext index on "goravel_posts" drop ("title", "body") else drop fulltext index on "goravel_posts"`,
+		s.grammar.CompileDropFullText(mockBlueprint, &driver.Command{
+			Index:   "goravel_posts_title_body_fulltext",
+			Columns: []string{"title", "body"},
+		}))
+}
+
 func (s *GrammarSuite) TestCompileIndex() {
 	var mockBlueprint *mocksdriver.Blueprint
 
@@ -290,6 +447,103 @@ func (s *GrammarSuite) TestCompileIndex() {
 			},
 			expectSql: `create index "fk_users_role_id" on "goravel_users" ("role_id", "user_id")`,
 		},
+		{
+			name: "with IndexOptions",
+			command: &driver.Command{
+				Index:   "users_email_index",
+				Columns: []string{"email", "created_at desc"},
+				Algorithm: IndexOptions{
+					Type:            IndexNonClustered,
+					Include:         []string{"name"},
+					Where:           "[deleted_at] is null",
+					FillFactor:      80,
+					PadIndex:        true,
+					DataCompression: "PAGE",
+					Online:          true,
+					SortInTempDB:    true,
+				}.String(),
+			},
+			expectSql: `create nonclustered index "users_email_index" on "goravel_users" ("email", "created_at" desc) include ("name") where [deleted_at] is null ` +
+				`with (pad_index = on, fillfactor = 80, sort_in_tempdb = on, online = on, data_compression = page)`,
+		},
+		{
+			name: "with clustered",
+			command: &driver.Command{
+				Index:     "users_created_at_index",
+				Columns:   []string{"created_at"},
+				Algorithm: IndexClustered,
+			},
+			expectSql: `create clustered index "users_created_at_index" on "goravel_users" ("created_at")`,
+		},
+		{
+			name: "with ordered clustered columnstore",
+			command: &driver.Command{
+				Index:     "users_columnstore",
+				Algorithm: IndexOptions{Type: IndexClusteredColumnstore, Order: []string{"created_at"}, DataCompression: "columnstore_archive"}.String(),
+			},
+			expectSql: `create clustered columnstore index "users_columnstore" on "goravel_users" order ("created_at") with (data_compression = columnstore_archive)`,
+		},
+		{
+			name: "with nonclustered columnstore",
+			command: &driver.Command{
+				Index:     "users_columnstore",
+				Columns:   []string{"name", "created_at"},
+				Algorithm: IndexOptions{Type: IndexNonClusteredColumnstore, Where: "[deleted_at] is null"}.String(),
+			},
+			expectSql: `create nonclustered columnstore index "users_columnstore" on "goravel_users" ("name", "created_at") where [deleted_at] is null`,
+		},
+		{
+			name: "with include and order columns",
+			command: &driver.Command{
+				Index:     "users_columnstore",
+				Columns:   []string{"name"},
+				Algorithm: IndexOptions{Type: IndexNonClusteredColumnstore, Include: []string{"email", "created_at"}, Order: []string{"name"}}.String(),
+			},
+			expectSql: `create nonclustered columnstore index "users_columnstore" on "goravel_users" ("name") include ("email", "created_at") order ("name")`,
+		},
+		{
+			name: "with spatial",
+			command: &driver.Command{
+				Index:   "zones_area_spatial",
+				Columns: []string{"area"},
+				Algorithm: IndexOptions{
+					Type:           IndexSpatial,
+					Using:          SpatialGeometryGrid,
+					BoundingBox:    []float64{0, 0, 500.5, 200},
+					Grids:          []string{"low", "low", "medium", "high"},
+					CellsPerObject: 64,
+				}.String(),
+			},
+			expectSql: `create spatial index "zones_area_spatial" on "goravel_users" ("area") using geometry_grid ` +
+				`with (bounding_box = (0, 0, 500.5, 200), grids = (level_1 = low, level_2 = low, level_3 = medium, level_4 = high), cells_per_object = 64)`,
+		},
+		{
+			name: "with rebuild",
+			command: &driver.Command{
+				Index:     "users_columnstore",
+				Algorithm: IndexOptions{Rebuild: true, DataCompression: "columnstore_archive"}.String(),
+			},
+			expectSql: `alter index "users_columnstore" on "goravel_users" rebuild with (data_compression = columnstore_archive)`,
+		},
+		{
+			name: "with nonclustered primary key",
+			command: &driver.Command{
+				Index:     "users_id_primary",
+				Columns:   []string{"id"},
+				Algorithm: IndexOptions{Primary: true, Type: IndexNonClustered, FillFactor: 90}.String(),
+			},
+			expectSql: `alter table "goravel_users" add constraint "users_id_primary" primary key nonclustered ("id") with (fillfactor = 90)`,
+		},
+		{
+			name: "with the Type returned by GetIndexes",
+			command: &driver.Command{
+				Index:     "users_email_index",
+				Columns:   []string{"email", "created_at desc"},
+				Algorithm: processIndexType(`NONCLUSTERED include (name, nick"name) where ([deleted_at] IS NULL) with (pad_index = on, fillfactor = 80)`),
+			},
+			expectSql: `create nonclustered index "users_email_index" on "goravel_users" ("email", "created_at" desc) include ("name", "nick""name") ` +
+				`where ([deleted_at] IS NULL) with (pad_index = on, fillfactor = 80)`,
+		},
 	}
 
 	for _, test := range tests {
@@ -302,6 +556,33 @@ func (s *GrammarSuite) TestCompileIndex() {
 	}
 }
 
+func (s *GrammarSuite) TestCompileIndexes() {
+	sql, err := s.grammar.CompileIndexes("", "sales.users")
+	s.NoError(err)
+	s.Contains(sql, "string_agg(case when idx.type <> 5 then col.name + case when idxcol.is_descending_key = 1 then ' desc' else '' end end, ',') "+
+		"within group (order by idxcol.key_ordinal, idxcol.index_column_id) as columns")
+	s.Contains(sql, `idx.type_desc + isnull(' include (' + (select string_agg(inccol.name, ', ')`)
+	s.Contains(sql, "case when idx.has_filter = 1 then ' where ' + idx.filter_definition else '' end")
+	s.Contains(sql, "par.data_compression_desc not in ('NONE', 'COLUMNSTORE')")
+	s.Contains(sql, "and (idxcol.is_included_column = 0 or idx.type in (5, 6)) ")
+	s.Contains(sql, "where tbl.name = 'goravel_users' and scm.name = 'sales' ")
+}
+
+func (s *GrammarSuite) TestCompileUnique() {
+	mockBlueprint := mocksdriver.NewBlueprint(s.T())
+	mockBlueprint.EXPECT().GetTableName().Return("users").Twice()
+
+	s.Equal(`create unique index "users_email_unique" on "goravel_users" ("email")`, s.grammar.CompileUnique(mockBlueprint, &driver.Command{
+		Index:   "users_email_unique",
+		Columns: []string{"email"},
+	}))
+	s.Equal(`create unique nonclustered index "users_email_unique" on "goravel_users" ("email") where [email] is not null`, s.grammar.CompileUnique(mockBlueprint, &driver.Command{
+		Index:     "users_email_unique",
+		Columns:   []string{"email"},
+		Algorithm: IndexOptions{Type: IndexNonClustered, Where: "[email] is not null"}.String(),
+	}))
+}
+
 func (s *GrammarSuite) TestCompileJsonColumnsUpdate() {
 	tests := []struct {
 		name           string
@@ -573,6 +854,7 @@ func (s *GrammarSuite) TestGetColumns() {
 	mockColumn1.EXPECT().GetDefault().Return(nil).Once()
 	mockColumn1.EXPECT().GetNullable().Return(false).Once()
 	mockColumn1.EXPECT().GetAutoIncrement().Return(true).Once()
+	mockColumn1.EXPECT().GetGeneratedAs().Return("").Once()
 	mockColumn1.EXPECT().IsChange().Return(false).Twice()
 
 	mockColumn2.EXPECT().GetName().Return("name").Once()
@@ -646,9 +928,50 @@ func (s *GrammarSuite) TestModifyIncrement() {
 	mockBlueprint.EXPECT().HasCommand("primary").Return(false).Once()
 	mockColumn.EXPECT().GetType().Return("bigInteger").Once()
 	mockColumn.EXPECT().GetAutoIncrement().Return(true).Once()
+	mockColumn.EXPECT().GetGeneratedAs().Return("").Once()
 	mockColumn.EXPECT().IsChange().Return(false).Once()
 
 	s.Equal(" identity primary key", s.grammar.ModifyIncrement(mockBlueprint, mockColumn))
+
+	mockColumn = mocksdriver.NewColumnDefinition(s.T())
+	mockBlueprint.EXPECT().HasCommand("primary").Return(true).Once()
+	mockColumn.EXPECT().GetType().Return("bigInteger").Once()
+	mockColumn.EXPECT().GetAutoIncrement().Return(true).Once()
+	mockColumn.EXPECT().GetGeneratedAs().Return(Identity(1000, 10)).Once()
+	mockColumn.EXPECT().IsChange().Return(false).Once()
+
+	s.Equal(" identity(1000, 10)", s.grammar.ModifyIncrement(mockBlueprint, mockColumn))
+
+	mockColumn = mocksdriver.NewColumnDefinition(s.T())
+	mockColumn.EXPECT().GetType().Return("integer").Once()
+	mockColumn.EXPECT().GetAutoIncrement().Return(false).Once()
+	mockColumn.EXPECT().IsSetGeneratedAs().Return(true).Once()
+	mockColumn.EXPECT().GetGeneratedAs().Return("100, -1").Once()
+	mockColumn.EXPECT().IsChange().Return(false).Once()
+
+	s.Equal(" identity(100, -1)", s.grammar.ModifyIncrement(mockBlueprint, mockColumn))
+
+	mockColumn = mocksdriver.NewColumnDefinition(s.T())
+	mockColumn.EXPECT().GetType().Return("integer").Once()
+	mockColumn.EXPECT().GetAutoIncrement().Return(false).Once()
+	mockColumn.EXPECT().IsSetGeneratedAs().Return(false).Once()
+	mockColumn.EXPECT().IsChange().Return(false).Once()
+
+	s.Empty(s.grammar.ModifyIncrement(mockBlueprint, mockColumn))
+}
+
+func (s *GrammarSuite) TestTypeBinary() {
+	mockColumn := mocksdriver.NewColumnDefinition(s.T())
+	mockColumn.EXPECT().GetLength().Return(0).Twice()
+
+	s.Equal("binary", s.grammar.TypeBinary(mockColumn))
+	s.Equal("varbinary(max)", s.grammar.TypeVarBinary(mockColumn))
+
+	mockColumn = mocksdriver.NewColumnDefinition(s.T())
+	mockColumn.EXPECT().GetLength().Return(16).Times(3)
+
+	s.Equal("binary(16)", s.grammar.TypeBinary(mockColumn))
+	s.Equal("varbinary(16)", s.grammar.TypeVarBinary(mockColumn))
 }
 
 func (s *GrammarSuite) TestTypeBoolean() {
@@ -657,6 +980,19 @@ func (s *GrammarSuite) TestTypeBoolean() {
 	s.Equal("bit", s.grammar.TypeBoolean(mockColumn))
 }
 
+func (s *GrammarSuite) TestTypeDateTime2() {
+	mockColumn := mocksdriver.NewColumnDefinition(s.T())
+	mockColumn.EXPECT().GetUseCurrent().Return(true).Once()
+	mockColumn.EXPECT().Default(schema.Expression("CURRENT_TIMESTAMP")).Return(mockColumn).Once()
+	mockColumn.EXPECT().GetPrecision().Return(3).Twice()
+	s.Equal("datetime2(3)", s.grammar.TypeDateTime2(mockColumn))
+
+	mockColumn = mocksdriver.NewColumnDefinition(s.T())
+	mockColumn.EXPECT().GetUseCurrent().Return(false).Once()
+	mockColumn.EXPECT().GetPrecision().Return(0).Once()
+	s.Equal("datetime2", s.grammar.TypeDateTime2(mockColumn))
+}
+
 func (s *GrammarSuite) TestTypeDecimal() {
 	mockColumn := mocksdriver.NewColumnDefinition(s.T())
 	mockColumn.EXPECT().GetTotal().Return(4).Once()
@@ -684,6 +1020,29 @@ func (s *GrammarSuite) TestTypeFloat() {
 	s.Equal("float(2)", s.grammar.TypeFloat(mockColumn))
 }
 
+func (s *GrammarSuite) TestTypeSpatial() {
+	mockColumn := mocksdriver.NewColumnDefinition(s.T())
+
+	s.Equal("geography", s.grammar.TypeGeography(mockColumn))
+	s.Equal("geometry", s.grammar.TypeGeometry(mockColumn))
+}
+
+func (s *GrammarSuite) TestTypeNative() {
+	mockColumn := mocksdriver.NewColumnDefinition(s.T())
+
+	s.Equal("hierarchyid", s.grammar.TypeHierarchyId(mockColumn))
+	s.Equal("money", s.grammar.TypeMoney(mockColumn))
+	s.Equal("smallmoney", s.grammar.TypeSmallMoney(mockColumn))
+	s.Equal("rowversion", s.grammar.TypeRowVersion(mockColumn))
+	s.Equal("sql_variant", s.grammar.TypeSqlVariant(mockColumn))
+
+	mockColumn.EXPECT().GetUseCurrent().Return(false).Once()
+	s.Equal("smalldatetime", s.grammar.TypeSmallDateTime(mockColumn))
+
+	mockColumn.EXPECT().Default(schema.Expression("newsequentialid()")).Return(mockColumn).Once()
+	s.Equal("uniqueidentifier", s.grammar.TypeSequentialUuid(mockColumn))
+}
+
 func (s *GrammarSuite) TestTypeString() {
 	mockColumn1 := mocksdriver.NewColumnDefinition(s.T())
 	mockColumn1.EXPECT().GetLength().Return(100).Once()
@@ -696,6 +1055,32 @@ func (s *GrammarSuite) TestTypeString() {
 	s.Equal("nvarchar(255)", s.grammar.TypeString(mockColumn2))
 }
 
+func (s *GrammarSuite) TestUnicode() {
+	grammar := NewGrammar("goravel_").SetUnicode(UnicodeVarchar, "")
+
+	mockColumn := mocksdriver.NewColumnDefinition(s.T())
+	mockColumn.EXPECT().GetLength().Return(100).Once()
+	s.Equal("varchar(100)", grammar.TypeString(mockColumn))
+
+	mockColumn.EXPECT().GetLength().Return(2).Once()
+	s.Equal("char(2)", grammar.TypeChar(mockColumn))
+	s.Equal("varchar(max)", grammar.TypeText(mockColumn))
+
+	mockColumn.EXPECT().GetName().Return("a").Once()
+	mockColumn.EXPECT().GetAllowed().Return([]any{"a", "b"}).Once()
+	s.Equal(`varchar(255) check ("a" in ('a', 'b'))`, grammar.TypeEnum(mockColumn))
+
+	grammar = NewGrammar("goravel_").SetUnicode(UnicodeUTF8, "")
+
+	mockColumn = mocksdriver.NewColumnDefinition(s.T())
+	mockColumn.EXPECT().GetLength().Return(0).Once()
+	s.Equal("varchar(255) collate Latin1_General_100_CI_AS_SC_UTF8", grammar.TypeString(mockColumn))
+	s.Equal("varchar(max) collate Latin1_General_100_CI_AS_SC_UTF8", grammar.TypeLongText(mockColumn))
+
+	grammar = NewGrammar("goravel_").SetUnicode(UnicodeUTF8, "Japanese_XJIS_140_CI_AS_UTF8")
+	s.Equal("varchar(255) collate Japanese_XJIS_140_CI_AS_UTF8", grammar.TypeTinyText(mockColumn))
+}
+
 func (s *GrammarSuite) TestTypeTimestamp() {
 	mockColumn := mocksdriver.NewColumnDefinition(s.T())
 	mockColumn.EXPECT().GetUseCurrent().Return(true).Once()
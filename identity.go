@@ -0,0 +1,109 @@
+package sqlserver
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	identityStartRegexp     = regexp.MustCompile(`(?i)\bstart\s+with\s+(-?\d+)`)
+	identityIncrementRegexp = regexp.MustCompile(`(?i)\bincrement\s+by\s+(-?\d+)`)
+	identitySeedRegexp      = regexp.MustCompile(`^\s*(-?\d+)\s*(?:,\s*(-?\d+)\s*)?$`)
+)
+
+// Identity returns the options of an identity column for GeneratedAs, the format is the one of PostgreSQL so the
+// migrations work with both drivers:
+//
+//	table.BigIncrements("id").GeneratedAs(sqlserver.Identity(1000, 10))
+//	table.BigInteger("number").GeneratedAs(sqlserver.Identity(1, 1))
+func Identity(start, increment int64) string {
+	return fmt.Sprintf("start with %d increment by %d", start, increment)
+}
+
+// WithIdentityInsert runs fn with IDENTITY_INSERT on for the table, so the rows can be inserted with explicit
+// values of the identity column. The setting is scoped to a single connection of the pool, it's turned off
+// before the connection is returned to the pool. The table prefix of the connection is added to the table like
+// in the migrations.
+func WithIdentityInsert(ctx context.Context, db *sql.DB, table string, fn func(conn *sql.Conn) error) (err error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	name := quoteTable(tablePrefix(db), table)
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf("set identity_insert %s on", name)); err != nil {
+		return fmt.Errorf("set Sqlserver identity_insert error: %v", err)
+	}
+	defer func() {
+		if _, offErr := conn.ExecContext(context.WithoutCancel(ctx), fmt.Sprintf("set identity_insert %s off", name)); offErr != nil && err == nil {
+			err = fmt.Errorf("set Sqlserver identity_insert error: %v", offErr)
+		}
+	}()
+
+	return fn(conn)
+}
+
+// Reseed sets the current identity value of the table, the next row gets value plus the increment.
+func Reseed(ctx context.Context, db *sql.DB, table string, value int64) error {
+	if _, err := db.ExecContext(ctx, fmt.Sprintf("dbcc checkident (%s, reseed, %d) with no_infomsgs", quoteString(quoteTable(tablePrefix(db), table)), value)); err != nil {
+		return fmt.Errorf("reseed Sqlserver identity error: %v", err)
+	}
+
+	return nil
+}
+
+// ReseedToMax sets the current identity value of the table to the maximum value of the identity column, it's
+// useful after inserting rows with explicit values.
+func ReseedToMax(ctx context.Context, db *sql.DB, table string) error {
+	if _, err := db.ExecContext(ctx, fmt.Sprintf("dbcc checkident (%s, reseed) with no_infomsgs", quoteString(quoteTable(tablePrefix(db), table)))); err != nil {
+		return fmt.Errorf("reseed Sqlserver identity error: %v", err)
+	}
+
+	return nil
+}
+
+// identityOptions returns the seed and the increment of an identity column from the GeneratedAs expression, it's
+// either the options of Identity or "seed, increment". Any other expression is kept as given, so the server
+// rejects it instead of the column getting the default options.
+func identityOptions(expression string) string {
+	if expression == "" {
+		return ""
+	}
+
+	start, increment := "1", "1"
+	if matches := identitySeedRegexp.FindStringSubmatch(expression); matches != nil {
+		start = matches[1]
+		if matches[2] != "" {
+			increment = matches[2]
+		}
+	} else {
+		rest := identityStartRegexp.ReplaceAllString(identityIncrementRegexp.ReplaceAllString(expression, ""), "")
+		if strings.TrimSpace(rest) != "" {
+			return fmt.Sprintf("(%s)", expression)
+		}
+		if matches := identityStartRegexp.FindStringSubmatch(expression); matches != nil {
+			start = matches[1]
+		}
+		if matches := identityIncrementRegexp.FindStringSubmatch(expression); matches != nil {
+			increment = matches[1]
+		}
+	}
+
+	return fmt.Sprintf("(%s, %s)", start, increment)
+}
+
+// quoteTable quotes the parts of a table name that may have a schema, the prefix is added to the table but not to
+// the schema, like Wrap.Table does.
+func quoteTable(prefix, table string) string {
+	parts := strings.Split(table, ".")
+	parts[len(parts)-1] = prefix + parts[len(parts)-1]
+	for i, part := range parts {
+		parts[i] = quoteIdentifier(part)
+	}
+
+	return strings.Join(parts, ".")
+}
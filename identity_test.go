@@ -0,0 +1,75 @@
+package sqlserver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentityOptions(t *testing.T) {
+	tests := []struct {
+		expression string
+		expect     string
+	}{
+		{expression: "", expect: ""},
+		{expression: Identity(1000, 10), expect: "(1000, 10)"},
+		{expression: "start with 5", expect: "(5, 1)"},
+		{expression: "increment by -1", expect: "(1, -1)"},
+		{expression: "100", expect: "(100, 1)"},
+		{expression: "100, 5", expect: "(100, 5)"},
+		{expression: "increment by 2 start with 10", expect: "(10, 2)"},
+		{expression: "always", expect: "(always)"},
+		{expression: "start with 5 cache 10", expect: "(start with 5 cache 10)"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expression, func(t *testing.T) {
+			assert.Equal(t, test.expect, identityOptions(test.expression))
+		})
+	}
+}
+
+func TestWithIdentityInsert(t *testing.T) {
+	connector := &stubConnector{}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	storePrefix(db, "goravel_")
+
+	assert.NoError(t, WithIdentityInsert(context.Background(), db, "sales.users", func(conn *sql.Conn) error {
+		_, err := conn.ExecContext(context.Background(), "insert into sales.users (id, name) values (1, 'goravel')")
+
+		return err
+	}))
+
+	err := WithIdentityInsert(context.Background(), db, "users", func(conn *sql.Conn) error {
+		return errors.New("duplicate key")
+	})
+	assert.EqualError(t, err, "duplicate key")
+
+	assert.NoError(t, Reseed(context.Background(), db, "users", 100))
+	assert.NoError(t, ReseedToMax(context.Background(), db, "sales.users"))
+
+	assert.Equal(t, []string{
+		"set identity_insert [sales].[goravel_users] on",
+		"insert into sales.users (id, name) values (1, 'goravel')",
+		"set identity_insert [sales].[goravel_users] off",
+		"set identity_insert [goravel_users] on",
+		"set identity_insert [goravel_users] off",
+		"dbcc checkident (N'[goravel_users]', reseed, 100) with no_infomsgs",
+		"dbcc checkident (N'[sales].[goravel_users]', reseed) with no_infomsgs",
+	}, connector.queries)
+}
+
+func TestTablePrefix(t *testing.T) {
+	db := sql.OpenDB(&stubConnector{})
+	defer db.Close()
+
+	assert.Empty(t, tablePrefix(db))
+
+	storePrefix(db, "goravel_")
+	assert.Equal(t, "goravel_", tablePrefix(db))
+	assert.Empty(t, tablePrefix(sql.OpenDB(&stubConnector{})))
+}
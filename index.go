@@ -0,0 +1,246 @@
+package sqlserver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	IndexClustered    = "clustered"
+	IndexNonClustered = "nonclustered"
+	// IndexClusteredColumnstore stores the whole table by column, it has no key columns.
+	IndexClusteredColumnstore = "clustered columnstore"
+	// IndexNonClusteredColumnstore stores a copy of the columns by column next to the rowstore table.
+	IndexNonClusteredColumnstore = "nonclustered columnstore"
+	// IndexSpatial indexes a geography or a geometry column, the table must have a clustered primary key.
+	IndexSpatial = "spatial"
+
+	SpatialGeometryGrid      = "geometry_grid"
+	SpatialGeometryAutoGrid  = "geometry_auto_grid"
+	SpatialGeographyGrid     = "geography_grid"
+	SpatialGeographyAutoGrid = "geography_auto_grid"
+)
+
+// indexTypes maps the type_desc of sys.indexes to the type of the indexes returned by GetIndexes.
+var indexTypes = map[string]string{
+	"HEAP":                     "heap",
+	"CLUSTERED":                IndexClustered,
+	"NONCLUSTERED":             IndexNonClustered,
+	"CLUSTERED COLUMNSTORE":    IndexClusteredColumnstore,
+	"NONCLUSTERED COLUMNSTORE": IndexNonClusteredColumnstore,
+	"NONCLUSTERED HASH":        "hash",
+	"SPATIAL":                  IndexSpatial,
+	"XML":                      "xml",
+}
+
+// IndexOptions are the SQL Server options of an index, its String is passed to the Algorithm of an index, a
+// unique index or a primary key created by Index:
+//
+//	table.Index("email").Algorithm(sqlserver.IndexOptions{Include: []string{"name"}, Where: "[deleted_at] is null"}.String())
+//	table.Index("id").Name("users_id_primary").Algorithm(sqlserver.IndexOptions{Primary: true, Type: sqlserver.IndexNonClustered}.String())
+//
+// The Type of the indexes returned by GetIndexes has the same format, so it can be passed back to Algorithm. The
+// build options Online and SortInTempDB are not stored by SQL Server so they are not returned. The descending
+// keys are the columns with a " desc" suffix, like "created_at desc".
+type IndexOptions struct {
+	// Type is IndexClustered, IndexNonClustered, a columnstore type or IndexSpatial, the default of SQL Server is
+	// used if it's empty. A clustered columnstore index is created without columns.
+	Type string
+	// Primary creates a primary key constraint instead of an index.
+	Primary bool
+	// Rebuild rebuilds the existing index with the options instead of creating it, the columns are ignored.
+	Rebuild bool
+	// Include adds the non-key columns to the leaf level of a nonclustered index.
+	Include []string
+	// Order sorts the rowgroups of a columnstore index by the columns, it requires SQL Server 2022.
+	Order []string
+	// Using is the tessellation scheme of a spatial index, like SpatialGeographyGrid, the default of SQL Server is
+	// the auto grid of the column type.
+	Using string
+	// BoundingBox is the xmin, ymin, xmax and ymax of the grid of a geometry spatial index, it's required by
+	// SpatialGeometryGrid and SpatialGeometryAutoGrid.
+	BoundingBox []float64
+	// Grids are the densities of the levels of a spatial grid, from level 1 to 4: low, medium or high.
+	Grids []string
+	// CellsPerObject is the maximum number of cells per object of a spatial index.
+	CellsPerObject int
+	// Where filters the rows of a filtered index.
+	Where string
+	// FillFactor is the percentage of the leaf pages filled when the index is built, from 1 to 100.
+	FillFactor int
+	// PadIndex applies the fill factor to the intermediate pages.
+	PadIndex bool
+	// DataCompression is none, row or page, or columnstore or columnstore_archive for a columnstore index.
+	DataCompression string
+	// Online keeps the table available while the index is built, it requires the Enterprise or Developer edition.
+	Online bool
+	// SortInTempDB stores the intermediate sort results in tempdb.
+	SortInTempDB bool
+}
+
+func (r IndexOptions) String() string {
+	var clauses []string
+	if r.Rebuild {
+		clauses = append(clauses, "rebuild")
+	}
+	if r.Primary {
+		clauses = append(clauses, "primary key")
+	}
+	if r.Type != "" {
+		clauses = append(clauses, strings.ToLower(r.Type))
+	}
+	if len(r.Include) > 0 {
+		clauses = append(clauses, fmt.Sprintf("include (%s)", strings.Join(r.Include, ", ")))
+	}
+	if len(r.Order) > 0 {
+		clauses = append(clauses, fmt.Sprintf("order (%s)", strings.Join(r.Order, ", ")))
+	}
+	if r.Using != "" {
+		clauses = append(clauses, "using "+strings.ToLower(r.Using))
+	}
+	if r.Where != "" {
+		clauses = append(clauses, "where "+r.Where)
+	}
+
+	var options []string
+	if len(r.BoundingBox) > 0 {
+		box := make([]string, len(r.BoundingBox))
+		for i, value := range r.BoundingBox {
+			box[i] = strconv.FormatFloat(value, 'f', -1, 64)
+		}
+		options = append(options, fmt.Sprintf("bounding_box = (%s)", strings.Join(box, ", ")))
+	}
+	if len(r.Grids) > 0 {
+		grids := make([]string, len(r.Grids))
+		for i, grid := range r.Grids {
+			grids[i] = fmt.Sprintf("level_%d = %s", i+1, strings.ToLower(grid))
+		}
+		options = append(options, fmt.Sprintf("grids = (%s)", strings.Join(grids, ", ")))
+	}
+	if r.CellsPerObject > 0 {
+		options = append(options, fmt.Sprintf("cells_per_object = %d", r.CellsPerObject))
+	}
+	if r.PadIndex {
+		options = append(options, "pad_index = on")
+	}
+	if r.FillFactor > 0 {
+		options = append(options, fmt.Sprintf("fillfactor = %d", r.FillFactor))
+	}
+	if r.SortInTempDB {
+		options = append(options, "sort_in_tempdb = on")
+	}
+	if r.Online {
+		options = append(options, "online = on")
+	}
+	if r.DataCompression != "" {
+		options = append(options, "data_compression = "+strings.ToLower(r.DataCompression))
+	}
+	if len(options) > 0 {
+		clauses = append(clauses, fmt.Sprintf("with (%s)", strings.Join(options, ", ")))
+	}
+
+	return strings.Join(clauses, " ")
+}
+
+// indexAlgorithm is the Algorithm of an index split into whether it's a rebuild or a primary key, the type, the
+// include and order columns, which are wrapped by the grammar, and the clauses following them.
+type indexAlgorithm struct {
+	rebuild bool
+	primary bool
+	kind    string
+	include []string
+	order   []string
+	clauses string
+}
+
+func (r indexAlgorithm) columnstore() bool {
+	return strings.HasSuffix(r.kind, "columnstore")
+}
+
+// parseIndexAlgorithm parses the format of IndexOptions, the algorithms of the other drivers (btree, hash...) are
+// ignored.
+func parseIndexAlgorithm(algorithm string) indexAlgorithm {
+	var result indexAlgorithm
+	rest := strings.TrimSpace(algorithm)
+	if hasKeyword(rest, "rebuild") {
+		result.rebuild = true
+		rest = strings.TrimSpace(rest[len("rebuild"):])
+	}
+	if hasKeyword(rest, "primary key") {
+		result.primary = true
+		rest = strings.TrimSpace(rest[len("primary key"):])
+	}
+
+	for _, kind := range []string{IndexNonClusteredColumnstore, IndexClusteredColumnstore, "columnstore", IndexSpatial, IndexNonClustered, IndexClustered} {
+		if hasKeyword(rest, kind) {
+			result.kind = kind
+			rest = strings.TrimSpace(rest[len(kind):])
+			break
+		}
+	}
+
+	result.include, rest = parseIndexColumns(rest, "include")
+	result.order, rest = parseIndexColumns(rest, "order")
+
+	for _, keyword := range []string{"using", "where", "with"} {
+		if hasKeyword(rest, keyword) {
+			result.clauses = rest
+
+			return result
+		}
+	}
+
+	return result
+}
+
+// parseIndexColumns parses the parenthesized column list following the keyword, like include (name, email), and
+// returns the rest of the algorithm.
+func parseIndexColumns(algorithm, keyword string) ([]string, string) {
+	if !hasKeyword(algorithm, keyword) {
+		return nil, algorithm
+	}
+
+	rest := strings.TrimSpace(algorithm[len(keyword):])
+	end := strings.Index(rest, ")")
+	if !strings.HasPrefix(rest, "(") || end < 0 {
+		return nil, algorithm
+	}
+
+	var columns []string
+	for _, column := range strings.Split(rest[1:end], ",") {
+		if column = strings.TrimSpace(column); column != "" {
+			columns = append(columns, column)
+		}
+	}
+
+	return columns, strings.TrimSpace(rest[end+1:])
+}
+
+// hasKeyword reports whether the value starts with the keyword followed by a space, a parenthesis or nothing.
+func hasKeyword(value, keyword string) bool {
+	if len(value) < len(keyword) || !strings.EqualFold(value[:len(keyword)], keyword) {
+		return false
+	}
+
+	return len(value) == len(keyword) || value[len(keyword)] == ' ' || value[len(keyword)] == '('
+}
+
+// processIndexType maps the type_desc of an index returned by CompileIndexes, see indexTypes, the options following
+// it are kept as they are, in the format of IndexOptions.
+func processIndexType(indexType string) string {
+	lower := strings.ToLower(indexType)
+	end := len(indexType)
+	for _, keyword := range []string{" include (", " order (", " where ", " with ("} {
+		if i := strings.Index(lower, keyword); i >= 0 && i < end {
+			end = i
+		}
+	}
+
+	kind := lower[:end]
+	if mapped, ok := indexTypes[strings.ToUpper(kind)]; ok {
+		kind = mapped
+	}
+
+	return kind + indexType[end:]
+}
@@ -0,0 +1,357 @@
+package sqlserver
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	MetricTypeCounter = "counter"
+	MetricTypeGauge   = "gauge"
+)
+
+// Metric is a single sample published by the MetricsCollector.
+type Metric struct {
+	Labels map[string]string
+	Name   string
+	Help   string
+	Type   string
+	Value  float64
+}
+
+// MetricsRegistry receives the samples of every collection, implement it to forward
+// the samples to another metrics library.
+type MetricsRegistry interface {
+	Publish(metrics []Metric) error
+}
+
+type MetricsOptions struct {
+	// WaitStats samples sys.dm_os_wait_stats.
+	WaitStats bool
+	// Blocking samples the blocking chains of sys.dm_exec_requests.
+	Blocking bool
+	// QueryStore samples the top regressed queries of Query Store.
+	QueryStore bool
+	// Top limits the number of wait types and regressed queries, 10 by default.
+	Top int
+}
+
+// MetricsCollector collects the pool stats of every reader and writer of a connection,
+// and samples the server wait stats through the first writer.
+type MetricsCollector struct {
+	connection string
+	registry   MetricsRegistry
+	options    MetricsOptions
+}
+
+func NewMetricsCollector(connection string, registry MetricsRegistry, options MetricsOptions) *MetricsCollector {
+	if options.Top <= 0 {
+		options.Top = 10
+	}
+
+	return &MetricsCollector{
+		connection: connection,
+		registry:   registry,
+		options:    options,
+	}
+}
+
+// Collect samples the metrics and publishes them to the registry, the samples that can be
+// collected are published even if others fail.
+func (r *MetricsCollector) Collect(ctx context.Context) error {
+	var (
+		errs    []error
+		metrics []Metric
+		writer  *sql.DB
+	)
+
+	for _, key := range r.poolKeys() {
+		value, ok := pools.Load(key)
+		if !ok {
+			continue
+		}
+		db := value.(*sql.DB)
+		metrics = append(metrics, poolMetrics(key, db.Stats())...)
+		if writer == nil && key.Role == RoleWriter {
+			writer = db
+		}
+	}
+
+	if writer != nil {
+		labels := map[string]string{"connection": r.connection}
+		if r.options.WaitStats {
+			waitMetrics, err := r.waitStats(ctx, writer, labels)
+			metrics = append(metrics, waitMetrics...)
+			errs = append(errs, err)
+		}
+		if r.options.Blocking {
+			blockingMetrics, err := r.blocking(ctx, writer, labels)
+			metrics = append(metrics, blockingMetrics...)
+			errs = append(errs, err)
+		}
+		if r.options.QueryStore {
+			queryStoreMetrics, err := r.queryStore(ctx, writer, labels)
+			metrics = append(metrics, queryStoreMetrics...)
+			errs = append(errs, err)
+		}
+	}
+
+	errs = append(errs, r.registry.Publish(metrics))
+
+	return errors.Join(errs...)
+}
+
+func (r *MetricsCollector) poolKeys() []poolKey {
+	var keys []poolKey
+	pools.Range(func(key, _ any) bool {
+		if poolKey := key.(poolKey); poolKey.Connection == r.connection {
+			keys = append(keys, poolKey)
+		}
+
+		return true
+	})
+
+	// Writers first, so the first writer is stable between collections.
+	slices.SortFunc(keys, func(a, b poolKey) int {
+		if a.Role != b.Role {
+			return strings.Compare(b.Role, a.Role)
+		}
+		if a.Index != b.Index {
+			return cmp.Compare(a.Index, b.Index)
+		}
+
+		return cmp.Compare(a.id, b.id)
+	})
+
+	return keys
+}
+
+func (r *MetricsCollector) waitStats(ctx context.Context, db *sql.DB, labels map[string]string) ([]Metric, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("select top (%d) wait_type, waiting_tasks_count, wait_time_ms, signal_wait_time_ms "+
+		"from sys.dm_os_wait_stats "+
+		"where waiting_tasks_count > 0 and wait_type not in (%s) "+
+		"order by wait_time_ms desc", r.options.Top, strings.Join(NewWrap("").Quotes(benignWaitTypes), ", ")))
+	if err != nil {
+		return nil, fmt.Errorf("sample wait stats error: %w", err)
+	}
+	defer rows.Close()
+
+	var metrics []Metric
+	for rows.Next() {
+		var (
+			waitType                           string
+			waitingTasks, waitTime, signalWait float64
+		)
+		if err := rows.Scan(&waitType, &waitingTasks, &waitTime, &signalWait); err != nil {
+			return metrics, fmt.Errorf("sample wait stats error: %w", err)
+		}
+
+		waitLabels := withLabel(labels, "wait_type", waitType)
+		metrics = append(metrics,
+			Metric{Name: "sqlserver_wait_tasks_total", Help: "Number of waits on the wait type.", Type: MetricTypeCounter, Labels: waitLabels, Value: waitingTasks},
+			Metric{Name: "sqlserver_wait_seconds_total", Help: "Total wait time of the wait type.", Type: MetricTypeCounter, Labels: waitLabels, Value: waitTime / 1000},
+			Metric{Name: "sqlserver_signal_wait_seconds_total", Help: "Total signal wait time of the wait type.", Type: MetricTypeCounter, Labels: waitLabels, Value: signalWait / 1000},
+		)
+	}
+
+	return metrics, rows.Err()
+}
+
+func (r *MetricsCollector) blocking(ctx context.Context, db *sql.DB, labels map[string]string) ([]Metric, error) {
+	// A head blocker blocks other requests but is not blocked itself.
+	row := db.QueryRowContext(ctx, "select "+
+		"count(*), "+
+		"isnull(max(r.wait_time), 0), "+
+		"count(distinct case when not exists (select 1 from sys.dm_exec_requests as b where b.session_id = r.blocking_session_id and b.blocking_session_id <> 0) then r.blocking_session_id end) "+
+		"from sys.dm_exec_requests as r "+
+		"where r.blocking_session_id <> 0")
+
+	var blocked, maxWait, headBlockers float64
+	if err := row.Scan(&blocked, &maxWait, &headBlockers); err != nil {
+		return nil, fmt.Errorf("sample blocking chains error: %w", err)
+	}
+
+	return []Metric{
+		{Name: "sqlserver_blocked_requests", Help: "Number of requests waiting on another session.", Type: MetricTypeGauge, Labels: labels, Value: blocked},
+		{Name: "sqlserver_blocked_wait_seconds_max", Help: "Longest wait of a blocked request.", Type: MetricTypeGauge, Labels: labels, Value: maxWait / 1000},
+		{Name: "sqlserver_head_blockers", Help: "Number of sessions at the head of a blocking chain.", Type: MetricTypeGauge, Labels: labels, Value: headBlockers},
+	}, nil
+}
+
+func (r *MetricsCollector) queryStore(ctx context.Context, db *sql.DB, labels map[string]string) ([]Metric, error) {
+	// Compare the average duration of the last hour with the previous week.
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("with stats as ("+
+		"select p.query_id, "+
+		"avg(case when i.start_time >= dateadd(hour, -1, getutcdate()) then rs.avg_duration end) as recent, "+
+		"avg(case when i.start_time < dateadd(hour, -1, getutcdate()) then rs.avg_duration end) as history "+
+		"from sys.query_store_runtime_stats as rs "+
+		"join sys.query_store_plan as p on p.plan_id = rs.plan_id "+
+		"join sys.query_store_runtime_stats_interval as i on i.runtime_stats_interval_id = rs.runtime_stats_interval_id "+
+		"where i.start_time >= dateadd(day, -7, getutcdate()) "+
+		"group by p.query_id) "+
+		"select top (%d) query_id, recent, history from stats "+
+		"where recent > history and history > 0 "+
+		"order by recent / history desc", r.options.Top))
+	if err != nil {
+		return nil, fmt.Errorf("sample query store error: %w", err)
+	}
+	defer rows.Close()
+
+	var metrics []Metric
+	for rows.Next() {
+		var (
+			queryID         int64
+			recent, history float64
+		)
+		if err := rows.Scan(&queryID, &recent, &history); err != nil {
+			return metrics, fmt.Errorf("sample query store error: %w", err)
+		}
+
+		queryLabels := withLabel(labels, "query_id", strconv.FormatInt(queryID, 10))
+		metrics = append(metrics,
+			Metric{Name: "sqlserver_query_store_recent_duration_seconds", Help: "Average duration of the regressed query in the last hour.", Type: MetricTypeGauge, Labels: queryLabels, Value: recent / 1e6},
+			Metric{Name: "sqlserver_query_store_regression_ratio", Help: "Recent average duration divided by the average duration of the previous week.", Type: MetricTypeGauge, Labels: queryLabels, Value: recent / history},
+		)
+	}
+
+	return metrics, rows.Err()
+}
+
+func poolMetrics(key poolKey, stats sql.DBStats) []Metric {
+	labels := map[string]string{
+		"connection": key.Connection,
+		"role":       key.Role,
+		"index":      strconv.Itoa(key.Index),
+		"host":       key.Host,
+		"database":   key.Database,
+	}
+
+	return []Metric{
+		{Name: "sqlserver_pool_max_open_connections", Help: "Maximum number of open connections.", Type: MetricTypeGauge, Labels: labels, Value: float64(stats.MaxOpenConnections)},
+		{Name: "sqlserver_pool_open_connections", Help: "Number of established connections.", Type: MetricTypeGauge, Labels: labels, Value: float64(stats.OpenConnections)},
+		{Name: "sqlserver_pool_in_use_connections", Help: "Number of connections in use.", Type: MetricTypeGauge, Labels: labels, Value: float64(stats.InUse)},
+		{Name: "sqlserver_pool_idle_connections", Help: "Number of idle connections.", Type: MetricTypeGauge, Labels: labels, Value: float64(stats.Idle)},
+		{Name: "sqlserver_pool_wait_total", Help: "Total number of connections waited for.", Type: MetricTypeCounter, Labels: labels, Value: float64(stats.WaitCount)},
+		{Name: "sqlserver_pool_wait_seconds_total", Help: "Total time blocked waiting for a connection.", Type: MetricTypeCounter, Labels: labels, Value: stats.WaitDuration.Seconds()},
+		{Name: "sqlserver_pool_max_idle_closed_total", Help: "Total number of connections closed due to max idle connections.", Type: MetricTypeCounter, Labels: labels, Value: float64(stats.MaxIdleClosed)},
+		{Name: "sqlserver_pool_max_idle_time_closed_total", Help: "Total number of connections closed due to max idle time.", Type: MetricTypeCounter, Labels: labels, Value: float64(stats.MaxIdleTimeClosed)},
+		{Name: "sqlserver_pool_max_lifetime_closed_total", Help: "Total number of connections closed due to max lifetime.", Type: MetricTypeCounter, Labels: labels, Value: float64(stats.MaxLifetimeClosed)},
+	}
+}
+
+func withLabel(labels map[string]string, key, value string) map[string]string {
+	newLabels := make(map[string]string, len(labels)+1)
+	for k, v := range labels {
+		newLabels[k] = v
+	}
+	newLabels[key] = value
+
+	return newLabels
+}
+
+var _ http.Handler = &TextRegistry{}
+
+// TextRegistry keeps the last published samples and exposes them in the Prometheus text format.
+type TextRegistry struct {
+	metrics []Metric
+	mu      sync.RWMutex
+}
+
+func NewTextRegistry() *TextRegistry {
+	return &TextRegistry{}
+}
+
+func (r *TextRegistry) Publish(metrics []Metric) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.metrics = metrics
+
+	return nil
+}
+
+func (r *TextRegistry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = r.WriteTo(w)
+}
+
+func (r *TextRegistry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var (
+		builder strings.Builder
+		names   []string
+		groups  = make(map[string][]Metric)
+	)
+	for _, metric := range r.metrics {
+		if _, ok := groups[metric.Name]; !ok {
+			names = append(names, metric.Name)
+		}
+		groups[metric.Name] = append(groups[metric.Name], metric)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		group := groups[name]
+		fmt.Fprintf(&builder, "# HELP %s %s\n", name, escapeMetricText(group[0].Help, false))
+		fmt.Fprintf(&builder, "# TYPE %s %s\n", name, group[0].Type)
+		for _, metric := range group {
+			builder.WriteString(name)
+			if len(metric.Labels) > 0 {
+				keys := make([]string, 0, len(metric.Labels))
+				for key := range metric.Labels {
+					keys = append(keys, key)
+				}
+				sort.Strings(keys)
+
+				labels := make([]string, len(keys))
+				for i, key := range keys {
+					labels[i] = fmt.Sprintf(`%s="%s"`, key, escapeMetricText(metric.Labels[key], true))
+				}
+				builder.WriteString("{" + strings.Join(labels, ",") + "}")
+			}
+			builder.WriteString(" " + strconv.FormatFloat(metric.Value, 'g', -1, 64) + "\n")
+		}
+	}
+
+	n, err := io.WriteString(w, builder.String())
+
+	return int64(n), err
+}
+
+func escapeMetricText(value string, quote bool) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, "\n", `\n`)
+	if quote {
+		value = strings.ReplaceAll(value, `"`, `\"`)
+	}
+
+	return value
+}
+
+// benignWaitTypes are the idle and background waits that are excluded from the wait stats.
+var benignWaitTypes = []string{
+	"BROKER_EVENTHANDLER", "BROKER_RECEIVE_WAITFOR", "BROKER_TASK_STOP", "BROKER_TO_FLUSH", "BROKER_TRANSMITTER",
+	"CHECKPOINT_QUEUE", "CLR_AUTO_EVENT", "CLR_MANUAL_EVENT", "CLR_SEMAPHORE", "DIRTY_PAGE_POLL",
+	"DISPATCHER_QUEUE_SEMAPHORE", "FT_IFTS_SCHEDULER_IDLE_WAIT", "FT_IFTSHC_MUTEX", "HADR_FILESTREAM_IOMGR_IOCOMPLETION",
+	"HADR_WORK_QUEUE", "LAZYWRITER_SLEEP", "LOGMGR_QUEUE", "ONDEMAND_TASK_QUEUE", "PWAIT_ALL_COMPONENTS_INITIALIZED",
+	"QDS_ASYNC_QUEUE", "QDS_CLEANUP_STALE_QUERIES_TASK_MAIN_LOOP_SLEEP", "QDS_PERSIST_TASK_MAIN_LOOP_SLEEP",
+	"QDS_SHUTDOWN_QUEUE", "REQUEST_FOR_DEADLOCK_SEARCH", "RESOURCE_QUEUE", "SERVER_IDLE_CHECK", "SLEEP_BPOOL_FLUSH",
+	"SLEEP_DBSTARTUP", "SLEEP_DCOMSTARTUP", "SLEEP_MASTERDBREADY", "SLEEP_MASTERMDREADY", "SLEEP_MASTERUPGRADED",
+	"SLEEP_MSDBSTARTUP", "SLEEP_SYSTEMTASK", "SLEEP_TASK", "SLEEP_TEMPDBSTARTUP", "SNI_HTTP_ACCEPT",
+	"SOS_WORK_DISPATCHER", "SP_SERVER_DIAGNOSTICS_SLEEP", "SQLTRACE_BUFFER_FLUSH", "SQLTRACE_INCREMENTAL_FLUSH_SLEEP",
+	"SQLTRACE_WAIT_ENTRIES", "WAIT_FOR_RESULTS", "WAITFOR", "WAITFOR_TASKSHUTDOWN", "WAIT_XTP_CKPT_CLOSE",
+	"WAIT_XTP_HOST_WAIT", "WAIT_XTP_OFFLINE_CKPT_NEW_LOG", "WAIT_XTP_RECOVERY", "XE_DISPATCHER_JOIN",
+	"XE_DISPATCHER_WAIT", "XE_TIMER_EVENT",
+}
@@ -0,0 +1,70 @@
+package sqlserver_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/goravel/sqlserver"
+	"github.com/goravel/sqlserver/sqlservertest"
+)
+
+func TestMetricsCollectorServerStats(t *testing.T) {
+	fake := sqlservertest.New()
+	sqlserver.StorePool("metrics", sqlserver.RoleWriter, fake.DB())
+	t.Cleanup(sqlserver.ClearPools)
+
+	fake.Expect("sys.dm_os_wait_stats").
+		WillReturnRows([]string{"wait_type", "waiting_tasks_count", "wait_time_ms", "signal_wait_time_ms"},
+			[]any{"LCK_M_X", int64(4), int64(2500), int64(500)})
+	fake.Expect("sys.dm_exec_requests").
+		WillReturnRows([]string{"blocked", "max_wait", "head_blockers"}, []any{int64(2), int64(3000), int64(1)})
+	fake.Expect("sys.query_store_runtime_stats").
+		WillReturnRows([]string{"query_id", "recent", "history"}, []any{int64(42), float64(2e6), float64(5e5)})
+
+	registry := sqlserver.NewTextRegistry()
+	collector := sqlserver.NewMetricsCollector("metrics", registry, sqlserver.MetricsOptions{WaitStats: true, Blocking: true, QueryStore: true, Top: 5})
+	assert.NoError(t, collector.Collect(context.Background()))
+
+	var builder strings.Builder
+	_, err := registry.WriteTo(&builder)
+	assert.NoError(t, err)
+	body := builder.String()
+
+	assert.Contains(t, body, `sqlserver_wait_tasks_total{connection="metrics",wait_type="LCK_M_X"} 4`)
+	assert.Contains(t, body, `sqlserver_wait_seconds_total{connection="metrics",wait_type="LCK_M_X"} 2.5`)
+	assert.Contains(t, body, `sqlserver_signal_wait_seconds_total{connection="metrics",wait_type="LCK_M_X"} 0.5`)
+	assert.Contains(t, body, `sqlserver_blocked_requests{connection="metrics"} 2`)
+	assert.Contains(t, body, `sqlserver_blocked_wait_seconds_max{connection="metrics"} 3`)
+	assert.Contains(t, body, `sqlserver_head_blockers{connection="metrics"} 1`)
+	assert.Contains(t, body, `sqlserver_query_store_recent_duration_seconds{connection="metrics",query_id="42"} 2`)
+	assert.Contains(t, body, `sqlserver_query_store_regression_ratio{connection="metrics",query_id="42"} 4`)
+
+	sqls := fake.SQL()
+	assert.Len(t, sqls, 3)
+	assert.Contains(t, sqls[0], "select top (5) wait_type")
+	assert.Contains(t, sqls[2], "select top (5) query_id")
+}
+
+func TestMetricsCollectorServerStatsError(t *testing.T) {
+	fake := sqlservertest.New()
+	sqlserver.StorePool("metrics", sqlserver.RoleWriter, fake.DB())
+	t.Cleanup(sqlserver.ClearPools)
+
+	fake.Expect("sys.query_store_runtime_stats").WillReturnError(208, "Invalid object name 'sys.query_store_runtime_stats'.")
+	fake.Expect("sys.dm_exec_requests").
+		WillReturnRows([]string{"blocked", "max_wait", "head_blockers"}, []any{int64(0), int64(0), int64(0)})
+
+	registry := sqlserver.NewTextRegistry()
+	err := sqlserver.NewMetricsCollector("metrics", registry, sqlserver.MetricsOptions{WaitStats: true, Blocking: true, QueryStore: true}).
+		Collect(context.Background())
+	assert.ErrorContains(t, err, "sample query store error: mssql: Invalid object name")
+
+	var builder strings.Builder
+	_, _ = registry.WriteTo(&builder)
+	// The samples collected before the error are published.
+	assert.Contains(t, builder.String(), `sqlserver_blocked_requests{connection="metrics"} 0`)
+	assert.Contains(t, builder.String(), `sqlserver_pool_open_connections{connection="metrics",database="",host="",index="0",role="writer"}`)
+}
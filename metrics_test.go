@@ -0,0 +1,72 @@
+package sqlserver
+
+import (
+	"context"
+	"database/sql"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/goravel/sqlserver/contracts"
+)
+
+func TestMetricsCollector(t *testing.T) {
+	writer := sql.OpenDB(&stubConnector{})
+	reader := sql.OpenDB(&stubConnector{})
+	writer.SetMaxOpenConns(10)
+	t.Cleanup(func() {
+		pools.Clear()
+		assert.NoError(t, writer.Close())
+		assert.NoError(t, reader.Close())
+	})
+
+	pools.Store(newPoolKey(contracts.FullConfig{Connection: "metrics", Config: contracts.Config{Host: "127.0.0.1", Port: 1433, Database: "goravel"}}, RoleWriter, 0), writer)
+	pools.Store(newPoolKey(contracts.FullConfig{Connection: "metrics", Config: contracts.Config{Host: "127.0.0.2", Port: 1433, Database: "goravel"}}, RoleReader, 0), reader)
+	pools.Store(newPoolKey(contracts.FullConfig{Connection: "metrics", Config: contracts.Config{Host: "127.0.0.2", Port: 1433, Database: "goravel"}}, RoleReader, 1), reader)
+	pools.Store(newPoolKey(contracts.FullConfig{Connection: "other", Config: contracts.Config{Host: "127.0.0.3"}}, RoleWriter, 0), writer)
+
+	registry := NewTextRegistry()
+	assert.NoError(t, NewMetricsCollector("metrics", registry, MetricsOptions{}).Collect(context.Background()))
+
+	recorder := httptest.NewRecorder()
+	registry.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body := recorder.Body.String()
+
+	assert.Contains(t, body, "# HELP sqlserver_pool_max_open_connections Maximum number of open connections.\n# TYPE sqlserver_pool_max_open_connections gauge\n")
+	assert.Contains(t, body, `sqlserver_pool_max_open_connections{connection="metrics",database="goravel",host="127.0.0.1:1433",index="0",role="writer"} 10`)
+	assert.Contains(t, body, `sqlserver_pool_max_open_connections{connection="metrics",database="goravel",host="127.0.0.2:1433",index="0",role="reader"} 0`)
+	assert.Contains(t, body, `sqlserver_pool_max_open_connections{connection="metrics",database="goravel",host="127.0.0.2:1433",index="1",role="reader"} 0`)
+	assert.Contains(t, body, "# TYPE sqlserver_pool_wait_total counter\n")
+	assert.NotContains(t, body, `connection="other"`)
+	assert.Equal(t, 1, strings.Count(body, "# TYPE sqlserver_pool_open_connections"))
+}
+
+func TestOpenPool(t *testing.T) {
+	key := newPoolKey(contracts.FullConfig{Connection: "metrics", Config: contracts.Config{Host: "127.0.0.1"}}, RoleWriter, 0)
+	other := newPoolKey(contracts.FullConfig{Connection: "metrics", Config: contracts.Config{Host: "127.0.0.1"}}, RoleWriter, 0)
+	assert.NotEqual(t, key, other)
+
+	db := openPool(key, &stubConnector{})
+	_, ok := pools.Load(key)
+	assert.True(t, ok)
+
+	assert.NoError(t, db.Close())
+	_, ok = pools.Load(key)
+	assert.False(t, ok)
+}
+
+func TestTextRegistry(t *testing.T) {
+	registry := NewTextRegistry()
+	assert.NoError(t, registry.Publish([]Metric{
+		{Name: "b", Help: "B\nhelp.", Type: MetricTypeGauge, Value: 1.5},
+		{Name: "a", Help: "A help.", Type: MetricTypeCounter, Labels: map[string]string{"z": "1", "y": `"quoted"`}, Value: 2},
+	}))
+
+	var builder strings.Builder
+	_, err := registry.WriteTo(&builder)
+	assert.NoError(t, err)
+	assert.Equal(t, "# HELP a A help.\n# TYPE a counter\na{y=\"\\\"quoted\\\"\",z=\"1\"} 2\n"+
+		"# HELP b B\\nhelp.\n# TYPE b gauge\nb 1.5\n", builder.String())
+}
@@ -0,0 +1,388 @@
+package sqlserver
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/golang-sql/civil"
+	mssql "github.com/microsoft/go-mssqldb"
+)
+
+const (
+	// ParameterSizingBucket declares the string and binary parameters with a fixed size
+	// (nvarchar(4000) / nvarchar(max), varchar(8000) / varchar(max), varbinary(8000) / varbinary(max)),
+	// so the same statement reuses one cached plan whatever the length of the values.
+	ParameterSizingBucket = "bucket"
+)
+
+var (
+	_ driver.Conn               = &parameterConn{}
+	_ driver.ConnBeginTx        = &parameterConn{}
+	_ driver.ConnPrepareContext = &parameterConn{}
+	_ driver.NamedValueChecker  = &parameterConn{}
+	_ driver.StmtExecContext    = &parameterStmt{}
+	_ driver.StmtQueryContext   = &parameterStmt{}
+)
+
+type ParameterOptions struct {
+	// Sizing normalizes the parameter declarations, see ParameterSizingBucket.
+	Sizing string
+	// Varchar sends the ASCII string parameters as varchar instead of nvarchar, so they
+	// can be compared with the varchar columns without an implicit conversion. It applies to
+	// every string parameter of the connection, including the ones of the nvarchar columns,
+	// which are converted by the server; a value typed as mssql.NVarCharMax is kept as it is.
+	Varchar bool
+}
+
+func (r ParameterOptions) enabled() bool {
+	return r.Sizing == ParameterSizingBucket || r.Varchar
+}
+
+// parameterConnector normalizes the parameters of every statement according to the options.
+type parameterConnector struct {
+	connector driver.Connector
+	options   ParameterOptions
+}
+
+func newParameterConnector(connector driver.Connector, options ParameterOptions) *parameterConnector {
+	return &parameterConnector{
+		connector: connector,
+		options:   options,
+	}
+}
+
+func (r *parameterConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := r.connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &parameterConn{conn: conn, options: r.options}, nil
+}
+
+func (r *parameterConnector) Driver() driver.Driver {
+	return r.connector.Driver()
+}
+
+type parameterConn struct {
+	conn    driver.Conn
+	options ParameterOptions
+}
+
+func (r *parameterConn) Begin() (driver.Tx, error) {
+	return r.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (r *parameterConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginTx, ok := r.conn.(driver.ConnBeginTx); ok {
+		return beginTx.BeginTx(ctx, opts)
+	}
+
+	return r.conn.Begin() //nolint:staticcheck
+}
+
+func (r *parameterConn) CheckNamedValue(value *driver.NamedValue) error {
+	if r.options.Varchar {
+		if str, ok := value.Value.(string); ok && isASCII(str) {
+			value.Value = mssql.VarChar(str)
+		}
+	}
+
+	if checker, ok := r.conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+
+	return driver.ErrSkip
+}
+
+func (r *parameterConn) Close() error {
+	return r.conn.Close()
+}
+
+func (r *parameterConn) IsValid() bool {
+	if validator, ok := r.conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+
+	return true
+}
+
+func (r *parameterConn) Ping(ctx context.Context) error {
+	if pinger, ok := r.conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+
+	return nil
+}
+
+func (r *parameterConn) Prepare(query string) (driver.Stmt, error) {
+	return r.PrepareContext(context.Background(), query)
+}
+
+func (r *parameterConn) PrepareContext(_ context.Context, query string) (driver.Stmt, error) {
+	// The statement is prepared when it's executed, because the declarations depend on the values.
+	return &parameterStmt{conn: r, query: query}, nil
+}
+
+func (r *parameterConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := r.conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+
+	return nil
+}
+
+// Unwrap returns the underlying go-mssqldb connection, it can be used in sql.Conn.Raw.
+func (r *parameterConn) Unwrap() driver.Conn {
+	return r.conn
+}
+
+func (r *parameterConn) prepare(ctx context.Context, query string, args []driver.NamedValue) (driver.Stmt, error) {
+	if r.options.Sizing == ParameterSizingBucket {
+		if newQuery, ok := CompileParameters(query, args); ok {
+			query = newQuery
+		}
+	}
+
+	if prepare, ok := r.conn.(driver.ConnPrepareContext); ok {
+		return prepare.PrepareContext(ctx, query)
+	}
+
+	return r.conn.Prepare(query)
+}
+
+type parameterStmt struct {
+	conn  *parameterConn
+	query string
+}
+
+func (r *parameterStmt) Close() error {
+	return nil
+}
+
+func (r *parameterStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return r.ExecContext(context.Background(), valuesToNamedValues(args))
+}
+
+func (r *parameterStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	stmt, err := r.conn.prepare(ctx, r.query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	if exec, ok := stmt.(driver.StmtExecContext); ok {
+		return exec.ExecContext(ctx, args)
+	}
+
+	return stmt.Exec(namedValuesToValues(args)) //nolint:staticcheck
+}
+
+func (r *parameterStmt) NumInput() int {
+	return -1
+}
+
+func (r *parameterStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return r.QueryContext(context.Background(), valuesToNamedValues(args))
+}
+
+func (r *parameterStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	stmt, err := r.conn.prepare(ctx, r.query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows driver.Rows
+	if query, ok := stmt.(driver.StmtQueryContext); ok {
+		rows, err = query.QueryContext(ctx, args)
+	} else {
+		rows, err = stmt.Query(namedValuesToValues(args)) //nolint:staticcheck
+	}
+	if err != nil {
+		_ = stmt.Close()
+		return nil, err
+	}
+
+	return &parameterRows{Rows: rows, stmt: stmt}, nil
+}
+
+// parameterRows closes the statement once the rows are consumed.
+type parameterRows struct {
+	driver.Rows
+	stmt driver.Stmt
+}
+
+func (r *parameterRows) Close() error {
+	err := r.Rows.Close()
+	_ = r.stmt.Close()
+
+	return err
+}
+
+// CompileParameters wraps a parameterized statement in sp_executesql with bucketed declarations:
+//
+//	exec sp_executesql N'select * from users where name = @p1', N'@p1 nvarchar(4000)', @p1 = @p1
+//
+// The go-mssqldb driver declares the outer parameters by the size of each value, but the plan of the
+// inner statement is only compiled once per declaration. It returns false when the statement
+// can't be wrapped, for example when a parameter is an output parameter or a table-valued parameter.
+func CompileParameters(query string, args []driver.NamedValue) (string, bool) {
+	if len(args) == 0 || hasPlaceholder(query) {
+		return query, false
+	}
+
+	declarations := make([]string, len(args))
+	assignments := make([]string, len(args))
+	for i, arg := range args {
+		declaration, ok := parameterDeclaration(arg.Value)
+		if !ok {
+			return query, false
+		}
+
+		name := "@" + arg.Name
+		if arg.Name == "" {
+			name = fmt.Sprintf("@p%d", arg.Ordinal)
+		}
+
+		declarations[i] = name + " " + declaration
+		assignments[i] = name + " = " + name
+	}
+
+	return fmt.Sprintf("exec sp_executesql N'%s', N'%s', %s",
+		strings.ReplaceAll(query, "'", "''"),
+		strings.Join(declarations, ", "),
+		strings.Join(assignments, ", "),
+	), true
+}
+
+// hasPlaceholder reports whether the query has a positional ? placeholder, the question marks of the strings, the
+// quoted identifiers and the comments are ignored.
+func hasPlaceholder(query string) bool {
+	var (
+		// comment is the depth of the nested block comments.
+		comment int
+		// quote is the closing character of the string or the quoted identifier.
+		quote byte
+	)
+	for i := 0; i < len(query); i++ {
+		current := query[i]
+		var next byte
+		if i+1 < len(query) {
+			next = query[i+1]
+		}
+
+		switch {
+		case quote != 0:
+			if current == quote {
+				if next == quote {
+					i++
+				} else {
+					quote = 0
+				}
+			}
+		case current == '/' && next == '*':
+			comment++
+			i++
+		case comment > 0:
+			if current == '*' && next == '/' {
+				comment--
+				i++
+			}
+		case current == '-' && next == '-':
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				return false
+			}
+			i += end
+		case current == '\'' || current == '"':
+			quote = current
+		case current == '[':
+			quote = ']'
+		case current == '?':
+			return true
+		}
+	}
+
+	return false
+}
+
+func parameterDeclaration(value any) (string, bool) {
+	switch value := value.(type) {
+	case nil:
+		return "nvarchar(4000)", true
+	case string:
+		return bucket("nvarchar", len(utf16.Encode([]rune(value))), 4000), true
+	case mssql.NChar:
+		return bucket("nvarchar", len(utf16.Encode([]rune(string(value)))), 4000), true
+	case mssql.NVarCharMax:
+		return "nvarchar(max)", true
+	case mssql.VarChar:
+		return bucket("varchar", len(value), 8000), true
+	case mssql.VarCharMax:
+		return "varchar(max)", true
+	case []byte:
+		return bucket("varbinary", len(value), 8000), true
+	case bool:
+		return "bit", true
+	case int, int64:
+		return "bigint", true
+	case int32:
+		return "int", true
+	case int16:
+		return "smallint", true
+	case int8:
+		return "smallint", true
+	case uint8:
+		return "tinyint", true
+	case float64:
+		return "float", true
+	case float32:
+		return "real", true
+	case time.Time, mssql.DateTimeOffset:
+		return "datetimeoffset(7)", true
+	case mssql.DateTime1:
+		return "datetime", true
+	case civil.Date:
+		return "date", true
+	case civil.DateTime:
+		return "datetime2(7)", true
+	case civil.Time:
+		return "time(7)", true
+	case mssql.UniqueIdentifier, mssql.NullUniqueIdentifier:
+		return "uniqueidentifier", true
+	case driver.Valuer:
+		converted, err := driver.DefaultParameterConverter.ConvertValue(value)
+		if err != nil {
+			return "", false
+		}
+		if _, ok := converted.(driver.Valuer); ok {
+			return "", false
+		}
+
+		return parameterDeclaration(converted)
+	default:
+		return "", false
+	}
+}
+
+func bucket(typeName string, size, limit int) string {
+	if size > limit {
+		return typeName + "(max)"
+	}
+
+	return fmt.Sprintf("%s(%d)", typeName, limit)
+}
+
+func isASCII(value string) bool {
+	for i := 0; i < len(value); i++ {
+		if value[i] > 127 {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,121 @@
+package sqlserver
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompileParameters(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		args        []driver.NamedValue
+		expectQuery string
+		expectOk    bool
+	}{
+		{
+			name:        "without parameters",
+			query:       "select * from users",
+			expectQuery: "select * from users",
+		},
+		{
+			name:  "strings are bucketed",
+			query: "select * from users where name = @p1 and email = @p2",
+			args: []driver.NamedValue{
+				{Ordinal: 1, Value: "goravel"},
+				{Ordinal: 2, Value: strings.Repeat("a", 4001)},
+			},
+			expectQuery: "exec sp_executesql N'select * from users where name = @p1 and email = @p2', " +
+				"N'@p1 nvarchar(4000), @p2 nvarchar(max)', @p1 = @p1, @p2 = @p2",
+			expectOk: true,
+		},
+		{
+			name:  "varchar and binary are bucketed",
+			query: "insert into files (name, content) values (@p1, @p2)",
+			args: []driver.NamedValue{
+				{Ordinal: 1, Value: mssql.VarChar("a.txt")},
+				{Ordinal: 2, Value: []byte("content")},
+			},
+			expectQuery: "exec sp_executesql N'insert into files (name, content) values (@p1, @p2)', " +
+				"N'@p1 varchar(8000), @p2 varbinary(8000)', @p1 = @p1, @p2 = @p2",
+			expectOk: true,
+		},
+		{
+			name:  "quotes are escaped and named parameters are kept",
+			query: "select * from users where name = 'it''s' and id = @id and created_at > @created_at and active = @p3",
+			args: []driver.NamedValue{
+				{Name: "id", Ordinal: 1, Value: int64(1)},
+				{Name: "created_at", Ordinal: 2, Value: time.Now()},
+				{Ordinal: 3, Value: true},
+			},
+			expectQuery: "exec sp_executesql N'select * from users where name = ''it''''s'' and id = @id and created_at > @created_at and active = @p3', " +
+				"N'@id bigint, @created_at datetimeoffset(7), @p3 bit', @id = @id, @created_at = @created_at, @p3 = @p3",
+			expectOk: true,
+		},
+		{
+			name:        "output parameters are not wrapped",
+			query:       "exec proc @p1",
+			args:        []driver.NamedValue{{Ordinal: 1, Value: sql.Out{Dest: new(int)}}},
+			expectQuery: "exec proc @p1",
+		},
+		{
+			name:  "question marks in strings and comments are not placeholders",
+			query: "select '?' as [why?], \"a?\" from users -- where id = ?\nwhere /* ? */ id = @p1",
+			args:  []driver.NamedValue{{Ordinal: 1, Value: int64(1)}},
+			expectQuery: "exec sp_executesql N'select ''?'' as [why?], \"a?\" from users -- where id = ?\nwhere /* ? */ id = @p1', " +
+				"N'@p1 bigint', @p1 = @p1",
+			expectOk: true,
+		},
+		{
+			name:        "positional placeholders are not wrapped",
+			query:       "select * from users where id = ?",
+			args:        []driver.NamedValue{{Ordinal: 1, Value: int64(1)}},
+			expectQuery: "select * from users where id = ?",
+		},
+		{
+			name:        "positional placeholders after comments are not wrapped",
+			query:       "select * from users /* a /* nested ? */ comment */ -- ?\nwhere id = ?",
+			args:        []driver.NamedValue{{Ordinal: 1, Value: int64(1)}},
+			expectQuery: "select * from users /* a /* nested ? */ comment */ -- ?\nwhere id = ?",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query, ok := CompileParameters(test.query, test.args)
+			assert.Equal(t, test.expectQuery, query)
+			assert.Equal(t, test.expectOk, ok)
+		})
+	}
+}
+
+func TestParameterConnector(t *testing.T) {
+	connector := &stubConnector{}
+	db := sql.OpenDB(newParameterConnector(connector, ParameterOptions{Sizing: ParameterSizingBucket, Varchar: true}))
+	t.Cleanup(func() {
+		assert.NoError(t, db.Close())
+	})
+
+	_, err := db.Exec("update users set name = @p1 where email = @p2", "goravel", "gö")
+	assert.NoError(t, err)
+
+	rows, err := db.Query("select * from users where id = @p1", 1)
+	assert.NoError(t, err)
+	assert.NoError(t, rows.Close())
+
+	// Varchar applies to the whole connection, a typed nvarchar value is kept.
+	_, err = db.Exec("update users set nickname = @p1 where name = @p2", mssql.NVarCharMax("goravel"), "goravel")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"exec sp_executesql N'update users set name = @p1 where email = @p2', N'@p1 varchar(8000), @p2 nvarchar(4000)', @p1 = @p1, @p2 = @p2",
+		"exec sp_executesql N'select * from users where id = @p1', N'@p1 bigint', @p1 = @p1",
+		"exec sp_executesql N'update users set nickname = @p1 where name = @p2', N'@p1 nvarchar(max), @p2 varchar(8000)', @p1 = @p1, @p2 = @p2",
+	}, connector.queries)
+}
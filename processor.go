@@ -25,6 +25,7 @@ func (r Processor) ProcessColumns(dbColumns []driver.DBColumn) []driver.Column {
 			Collation:     dbColumn.Collation,
 			Comment:       dbColumn.Comment,
 			Default:       dbColumn.Default,
+			Extra:         dbColumn.Extra,
 			Name:          dbColumn.Name,
 			Nullable:      cast.ToBool(dbColumn.Nullable),
 			Type:          getType(dbColumn),
@@ -55,10 +56,14 @@ func (r Processor) ProcessForeignKeys(dbForeignKeys []driver.DBForeignKey) []dri
 func (r Processor) ProcessIndexes(dbIndexes []driver.DBIndex) []driver.Index {
 	var indexes []driver.Index
 	for _, dbIndex := range dbIndexes {
+		var columns []string
+		if dbIndex.Columns != "" {
+			columns = strings.Split(dbIndex.Columns, ",")
+		}
 		indexes = append(indexes, driver.Index{
-			Columns: strings.Split(dbIndex.Columns, ","),
+			Columns: columns,
 			Name:    strings.ToLower(dbIndex.Name),
-			Type:    strings.ToLower(dbIndex.Type),
+			Type:    processIndexType(dbIndex.Type),
 			Primary: dbIndex.Primary,
 			Unique:  dbIndex.Unique,
 		})
@@ -77,13 +82,19 @@ func getType(dbColumn driver.DBColumn) string {
 	case "binary", "varbinary", "char", "varchar", "nchar", "nvarchar":
 		if dbColumn.Length == -1 {
 			typeName = dbColumn.TypeName + "(max)"
+		} else if dbColumn.TypeName == "nchar" || dbColumn.TypeName == "nvarchar" {
+			// The length of the unicode types is in bytes, 2 per character.
+			typeName = fmt.Sprintf("%s(%d)", dbColumn.TypeName, dbColumn.Length/2)
 		} else {
 			typeName = fmt.Sprintf("%s(%d)", dbColumn.TypeName, dbColumn.Length)
 		}
 	case "decimal", "numeric":
 		typeName = fmt.Sprintf("%s(%d,%d)", dbColumn.TypeName, dbColumn.Precision, dbColumn.Places)
-	case "float", "datetime2", "datetimeoffset", "time":
+	case "float":
 		typeName = fmt.Sprintf("%s(%d)", dbColumn.TypeName, dbColumn.Precision)
+	case "datetime2", "datetimeoffset", "time":
+		// The fractional seconds precision is the scale, the precision is the number of characters.
+		typeName = fmt.Sprintf("%s(%d)", dbColumn.TypeName, dbColumn.Places)
 	default:
 		typeName = dbColumn.TypeName
 	}
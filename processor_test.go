@@ -39,6 +39,15 @@ func (s *ProcessorTestSuite) TestProcessColumns() {
 				{Autoincrement: false, Collation: "utf8_general_ci", Comment: "user name", Default: "default_name", Name: "name", Nullable: true, Type: "varchar(10)", TypeName: "varchar"},
 			},
 		},
+		{
+			name: "ComputedColumn",
+			dbColumns: []driver.DBColumn{
+				{Name: "total", TypeName: "int", Nullable: "true", Extra: "as ([price]*[quantity]) persisted"},
+			},
+			expected: []driver.Column{
+				{Extra: "as ([price]*[quantity]) persisted", Name: "total", Nullable: true, Type: "int", TypeName: "int"},
+			},
+		},
 		{
 			name:      "EmptyInput",
 			dbColumns: []driver.DBColumn{},
@@ -71,6 +80,24 @@ func (s *ProcessorTestSuite) TestProcessColumns() {
 	}
 }
 
+func (s *ProcessorTestSuite) TestProcessIndexes() {
+	s.Equal([]driver.Index{
+		{Name: "users_pkey", Type: "clustered", Columns: []string{"id"}, Primary: true, Unique: true},
+		{
+			Name:    "users_email_index",
+			Type:    `nonclustered include (Name) where ([Deleted_At] IS NULL) with (fillfactor = 80)`,
+			Columns: []string{"email", "created_at desc"},
+		},
+		{Name: "users_columnstore", Type: "clustered columnstore with (data_compression = columnstore_archive)"},
+		{Name: "users_ncci", Type: "nonclustered columnstore", Columns: []string{"name", "created_at"}},
+	}, s.processor.ProcessIndexes([]driver.DBIndex{
+		{Name: "users_pkey", Type: "CLUSTERED", Columns: "id", Primary: true, Unique: true},
+		{Name: "users_email_index", Type: `NONCLUSTERED include (Name) where ([Deleted_At] IS NULL) with (fillfactor = 80)`, Columns: "email,created_at desc"},
+		{Name: "users_columnstore", Type: "CLUSTERED COLUMNSTORE with (data_compression = columnstore_archive)"},
+		{Name: "users_ncci", Type: "NONCLUSTERED COLUMNSTORE", Columns: "name,created_at"},
+	}))
+}
+
 func (s *ProcessorTestSuite) TestProcessForeignKeys() {
 	tests := []struct {
 		name          string
@@ -131,6 +158,46 @@ func TestGetType(t *testing.T) {
 			dbColumn: driver.DBColumn{TypeName: "float", Precision: 5},
 			expected: "float(5)",
 		},
+		{
+			name:     "VarbinaryWithMaxLength",
+			dbColumn: driver.DBColumn{TypeName: "varbinary", Length: -1},
+			expected: "varbinary(max)",
+		},
+		{
+			name:     "NvarcharWithSpecificLength",
+			dbColumn: driver.DBColumn{TypeName: "nvarchar", Length: 200},
+			expected: "nvarchar(100)",
+		},
+		{
+			name:     "Datetime2WithPrecision",
+			dbColumn: driver.DBColumn{TypeName: "datetime2", Precision: 23, Places: 3},
+			expected: "datetime2(3)",
+		},
+		{
+			name:     "Datetime2WithZeroPrecision",
+			dbColumn: driver.DBColumn{TypeName: "datetime2", Precision: 19, Places: 0},
+			expected: "datetime2(0)",
+		},
+		{
+			name:     "TimeWithPrecision",
+			dbColumn: driver.DBColumn{TypeName: "time", Precision: 16, Places: 7},
+			expected: "time(7)",
+		},
+		{
+			name:     "Smallmoney",
+			dbColumn: driver.DBColumn{TypeName: "smallmoney", Precision: 10, Places: 4},
+			expected: "smallmoney",
+		},
+		{
+			name:     "Smalldatetime",
+			dbColumn: driver.DBColumn{TypeName: "smalldatetime", Precision: 16},
+			expected: "smalldatetime",
+		},
+		{
+			name:     "Rowversion",
+			dbColumn: driver.DBColumn{TypeName: "rowversion", Length: 8},
+			expected: "rowversion",
+		},
 		{
 			name:     "DefaultTypeName",
 			dbColumn: driver.DBColumn{TypeName: "int"},
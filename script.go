@@ -0,0 +1,261 @@
+package sqlserver
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	mssql "github.com/microsoft/go-mssqldb"
+)
+
+var (
+	goRegexp     = regexp.MustCompile(`(?i)^\s*GO(?:\s+(\d+))?\s*(?:--.*)?$`)
+	setvarRegexp = regexp.MustCompile(`(?i)^\s*:setvar\s+(\w+)(?:\s+(?:"([^"]*)"|(\S+)))?\s*$`)
+	varRegexp    = regexp.MustCompile(`\$\((\w+)\)`)
+)
+
+// Execer executes a statement, *sql.DB, *sql.Conn and *sql.Tx implement it.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// ScriptError is returned by ExecScript when a batch fails.
+type ScriptError struct {
+	File  string
+	Batch int
+	Line  int
+	Err   error
+}
+
+func (r *ScriptError) Error() string {
+	file := r.File
+	if file == "" {
+		file = "script"
+	}
+
+	return fmt.Sprintf("%s:%d: batch %d: %v", file, r.Line, r.Batch, r.Err)
+}
+
+func (r *ScriptError) Unwrap() error {
+	return r.Err
+}
+
+type scriptBatch struct {
+	sql   string
+	line  int
+	count int
+}
+
+// ExecScript runs a sqlcmd style script: the batches are separated by GO (GO n runs a batch n times),
+// the $(var) variables are replaced by vars or by the :setvar commands of the script, and each batch is
+// sent as is, so the statements that must be the first of a batch (create procedure, for example) work.
+func ExecScript(ctx context.Context, db Execer, reader io.Reader, vars map[string]string) error {
+	return execScript(ctx, db, reader, "", vars)
+}
+
+// ExecScriptFile runs a script file, see ExecScript.
+func ExecScriptFile(ctx context.Context, db Execer, path string, vars map[string]string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return execScript(ctx, db, file, path, vars)
+}
+
+// ExecScriptDir runs the .sql files of a directory in the order of their names, see ExecScript.
+func ExecScriptDir(ctx context.Context, db Execer, dir string, vars map[string]string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return err
+	}
+	slices.Sort(paths)
+
+	for _, path := range paths {
+		if err := ExecScriptFile(ctx, db, path, vars); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func execScript(ctx context.Context, db Execer, reader io.Reader, file string, vars map[string]string) error {
+	batches, err := splitBatches(reader, file, vars)
+	if err != nil {
+		return err
+	}
+
+	for i, batch := range batches {
+		for range batch.count {
+			if _, err := db.ExecContext(ctx, batch.sql); err != nil {
+				return &ScriptError{File: file, Batch: i + 1, Line: errorLine(batch.line, err), Err: err}
+			}
+		}
+	}
+
+	return nil
+}
+
+// errorLine returns the line of the script where a batch starting at line failed, the line of a server error is
+// relative to its batch.
+func errorLine(line int, err error) int {
+	var sqlErr mssql.Error
+	if errors.As(err, &sqlErr) && sqlErr.LineNo > 0 {
+		return line + int(sqlErr.LineNo) - 1
+	}
+
+	return line
+}
+
+// splitBatches splits a script by the GO lines outside of the strings, the quoted identifiers and the comments.
+func splitBatches(reader io.Reader, file string, vars map[string]string) ([]scriptBatch, error) {
+	variables := make(map[string]string, len(vars))
+	for name, value := range vars {
+		variables[strings.ToLower(name)] = value
+	}
+
+	var (
+		batches []scriptBatch
+		builder strings.Builder
+		scanner = bufio.NewScanner(reader)
+		state   scriptState
+		line    int
+		start   = 1
+	)
+
+	flush := func(count int) error {
+		sql, err := replaceVariables(builder.String(), variables)
+		if err != nil {
+			return &ScriptError{File: file, Batch: len(batches) + 1, Line: start, Err: err}
+		}
+		if strings.TrimSpace(sql) != "" {
+			batches = append(batches, scriptBatch{sql: sql, line: start, count: count})
+		}
+		builder.Reset()
+		start = line + 1
+
+		return nil
+	}
+
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+
+		if state.outside() {
+			if matches := goRegexp.FindStringSubmatch(text); matches != nil {
+				count := 1
+				if matches[1] != "" {
+					count, _ = strconv.Atoi(matches[1])
+				}
+				if err := flush(count); err != nil {
+					return nil, err
+				}
+
+				continue
+			}
+			if matches := setvarRegexp.FindStringSubmatch(text); matches != nil {
+				variables[strings.ToLower(matches[1])] = matches[2] + matches[3]
+				if builder.Len() == 0 {
+					start = line + 1
+				} else {
+					// Keeps the lines of the batch in line with the script.
+					builder.WriteByte('\n')
+				}
+
+				continue
+			}
+		}
+
+		if builder.Len() == 0 && strings.TrimSpace(text) == "" {
+			start = line + 1
+			continue
+		}
+
+		state.scan(text)
+		builder.WriteString(text)
+		builder.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(1); err != nil {
+		return nil, err
+	}
+
+	return batches, nil
+}
+
+func replaceVariables(sql string, variables map[string]string) (string, error) {
+	var err error
+	sql = varRegexp.ReplaceAllStringFunc(sql, func(match string) string {
+		name := varRegexp.FindStringSubmatch(match)[1]
+		value, ok := variables[strings.ToLower(name)]
+		if !ok && err == nil {
+			err = fmt.Errorf("'%s' scripting variable not defined", name)
+		}
+
+		return value
+	})
+
+	return sql, err
+}
+
+// scriptState tracks whether a line starts inside a string, a quoted identifier or a block comment,
+// where a GO line is a part of the batch.
+type scriptState struct {
+	// comment is the depth of the nested block comments.
+	comment int
+	// quote is the closing character of the string or the quoted identifier.
+	quote byte
+}
+
+func (r *scriptState) outside() bool {
+	return r.comment == 0 && r.quote == 0
+}
+
+func (r *scriptState) scan(line string) {
+	for i := 0; i < len(line); i++ {
+		current := line[i]
+		var next byte
+		if i+1 < len(line) {
+			next = line[i+1]
+		}
+
+		switch {
+		case r.quote != 0:
+			if current == r.quote {
+				if next == r.quote {
+					i++
+				} else {
+					r.quote = 0
+				}
+			}
+		case current == '/' && next == '*':
+			r.comment++
+			i++
+		case r.comment > 0:
+			if current == '*' && next == '/' {
+				r.comment--
+				i++
+			}
+		case current == '-' && next == '-':
+			return
+		case current == '\'' || current == '"':
+			r.quote = current
+		case current == '[':
+			r.quote = ']'
+		}
+	}
+}
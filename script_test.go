@@ -0,0 +1,132 @@
+package sqlserver
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitBatches(t *testing.T) {
+	tests := []struct {
+		name        string
+		script      string
+		vars        map[string]string
+		expect      []scriptBatch
+		expectError string
+	}{
+		{
+			name:   "single batch without GO",
+			script: "select 1",
+			expect: []scriptBatch{{sql: "select 1\n", line: 1, count: 1}},
+		},
+		{
+			name:   "GO separators and repeat count",
+			script: "create table users (id int)\nGO\n\ninsert into users values (1)\ngo 3 -- three rows\nselect * from users",
+			expect: []scriptBatch{
+				{sql: "create table users (id int)\n", line: 1, count: 1},
+				{sql: "insert into users values (1)\n", line: 4, count: 3},
+				{sql: "select * from users\n", line: 6, count: 1},
+			},
+		},
+		{
+			name:   "GO inside strings, identifiers and comments",
+			script: "select 'it''s\nGO\n', [a\nGO\n]\n/* outer /* inner */\nGO\n*/\n-- 'comment\nGO\nselect 2",
+			expect: []scriptBatch{
+				{sql: "select 'it''s\nGO\n', [a\nGO\n]\n/* outer /* inner */\nGO\n*/\n-- 'comment\n", line: 1, count: 1},
+				{sql: "select 2\n", line: 11, count: 1},
+			},
+		},
+		{
+			name:   "variables",
+			script: ":setvar Table users\nselect * from $(table) where name = N'$(Name)'",
+			vars:   map[string]string{"name": "goravel"},
+			expect: []scriptBatch{{sql: "select * from users where name = N'goravel'\n", line: 2, count: 1}},
+		},
+		{
+			name:        "undefined variable",
+			script:      "select 1\nGO\nselect $(missing)",
+			expectError: "script:3: batch 2: 'missing' scripting variable not defined",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			batches, err := splitBatches(strings.NewReader(test.script), "", test.vars)
+			if test.expectError != "" {
+				assert.EqualError(t, err, test.expectError)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expect, batches)
+		})
+	}
+}
+
+func TestExecScript(t *testing.T) {
+	connector := &stubConnector{}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	assert.NoError(t, ExecScript(context.Background(), db, strings.NewReader("insert into users values (1)\nGO 2\nselect 1"), nil))
+	assert.Equal(t, []string{"insert into users values (1)\n", "insert into users values (1)\n", "select 1\n"}, connector.queries)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "02_seed.sql"), []byte("select 2\nGO\n\nselect * from missing\nGO"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "01_schema.sql"), []byte("select 1"), 0o644))
+
+	connector.queries = nil
+	err := ExecScriptDir(context.Background(), &failingExecer{db: db}, dir, nil)
+	assert.EqualError(t, err, filepath.Join(dir, "02_seed.sql")+":4: batch 2: invalid object name")
+	assert.Equal(t, []string{"select 1\n", "select 2\n"}, connector.queries)
+}
+
+func TestExecScriptErrorLine(t *testing.T) {
+	db := &failingExecer{err: mssql.Error{Number: 208, LineNo: 3, Message: "Invalid object name 'missing'."}}
+
+	script := "select 1\nGO\n\n:setvar Table users\nselect 2\n:setvar Table missing\nselect * from $(Table)\nGO"
+	err := ExecScript(context.Background(), db, strings.NewReader(script), nil)
+
+	var scriptErr *ScriptError
+	require.ErrorAs(t, err, &scriptErr)
+	assert.Equal(t, 2, scriptErr.Batch)
+	assert.Equal(t, 7, scriptErr.Line)
+	assert.Equal(t, 3, strings.Count(db.queries[1], "\n"))
+
+	db = &failingExecer{err: errors.New("invalid object name")}
+	err = ExecScript(context.Background(), db, strings.NewReader(script), nil)
+	require.ErrorAs(t, err, &scriptErr)
+	assert.Equal(t, 5, scriptErr.Line)
+}
+
+// failingExecer fails the statements containing "missing" with err, the stub statements only fail in queries.
+type failingExecer struct {
+	db      *sql.DB
+	err     error
+	queries []string
+}
+
+func (r *failingExecer) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	r.queries = append(r.queries, query)
+	if strings.Contains(query, "missing") {
+		if r.err != nil {
+			return nil, r.err
+		}
+
+		return nil, errors.New("invalid object name")
+	}
+	if r.db == nil {
+		return driver.RowsAffected(0), nil
+	}
+
+	return r.db.ExecContext(ctx, query, args...)
+}
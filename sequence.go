@@ -0,0 +1,265 @@
+package sqlserver
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Queryer runs a query, *sql.DB, *sql.Conn and *sql.Tx implement it.
+type Queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// Sequence is a sequence object returned by GetSequences.
+type Sequence struct {
+	Schema    string
+	Name      string
+	Type      string
+	Start     int64
+	Increment int64
+	MinValue  int64
+	MaxValue  int64
+	Current   int64
+	// CacheSize is 0 when the sequence is not cached, and -1 when the cache size is chosen by the server.
+	CacheSize int64
+	Cycle     bool
+}
+
+// SequenceDefinition defines the options of a sequence object and returns the SQL to create, alter or drop it.
+// It's not a Blueprint command, the Blueprint of the framework can't be extended by a driver, so the SQL is run
+// through Schema().Sql in the migrations, and the table prefix of the connection is only added when it's set by
+// Prefix:
+//
+//	facades.Schema().Sql(sqlserver.NewSequence("sales.order_numbers").Prefix("goravel_").StartWith(1000).Cache(100).CreateSql())
+type SequenceDefinition struct {
+	name      string
+	prefix    string
+	dataType  string
+	start     *int64
+	increment *int64
+	minValue  *int64
+	maxValue  *int64
+	cache     *int64
+	cycle     *bool
+}
+
+// NewSequence defines a sequence, the name may have a schema. The sequence is a bigint by default.
+func NewSequence(name string) *SequenceDefinition {
+	return &SequenceDefinition{name: name}
+}
+
+// Prefix sets the table prefix of the connection, it's added to the name of the sequence but not to its schema.
+func (r *SequenceDefinition) Prefix(prefix string) *SequenceDefinition {
+	r.prefix = prefix
+
+	return r
+}
+
+// As sets the data type of the sequence, it's an integer type or a decimal with a scale of 0.
+func (r *SequenceDefinition) As(dataType string) *SequenceDefinition {
+	r.dataType = dataType
+
+	return r
+}
+
+// StartWith sets the first value of the sequence, AlterSql restarts the sequence with it.
+func (r *SequenceDefinition) StartWith(value int64) *SequenceDefinition {
+	r.start = &value
+
+	return r
+}
+
+func (r *SequenceDefinition) IncrementBy(value int64) *SequenceDefinition {
+	r.increment = &value
+
+	return r
+}
+
+func (r *SequenceDefinition) MinValue(value int64) *SequenceDefinition {
+	r.minValue = &value
+
+	return r
+}
+
+func (r *SequenceDefinition) MaxValue(value int64) *SequenceDefinition {
+	r.maxValue = &value
+
+	return r
+}
+
+// Cycle restarts the sequence from the minimum value, or the maximum value of a descending sequence, when it's
+// exhausted.
+func (r *SequenceDefinition) Cycle(cycle bool) *SequenceDefinition {
+	r.cycle = &cycle
+
+	return r
+}
+
+// Cache sets the number of values cached by the server, the sequence is not cached if the size is 0.
+func (r *SequenceDefinition) Cache(size int64) *SequenceDefinition {
+	r.cache = &size
+
+	return r
+}
+
+func (r *SequenceDefinition) CreateSql() string {
+	sql := "create sequence " + quoteTable(r.prefix, r.name)
+	dataType := r.dataType
+	if dataType == "" {
+		dataType = "bigint"
+	}
+	sql += " as " + dataType
+	if r.start != nil {
+		sql += fmt.Sprintf(" start with %d", *r.start)
+	}
+
+	return sql + r.options()
+}
+
+func (r *SequenceDefinition) AlterSql() string {
+	sql := "alter sequence " + quoteTable(r.prefix, r.name)
+	if r.start != nil {
+		sql += fmt.Sprintf(" restart with %d", *r.start)
+	}
+
+	return sql + r.options()
+}
+
+func (r *SequenceDefinition) DropSql() string {
+	return "drop sequence if exists " + quoteTable(r.prefix, r.name)
+}
+
+func (r *SequenceDefinition) options() string {
+	var options []string
+	if r.increment != nil {
+		options = append(options, fmt.Sprintf("increment by %d", *r.increment))
+	}
+	if r.minValue != nil {
+		options = append(options, fmt.Sprintf("minvalue %d", *r.minValue))
+	}
+	if r.maxValue != nil {
+		options = append(options, fmt.Sprintf("maxvalue %d", *r.maxValue))
+	}
+	if r.cycle != nil {
+		if *r.cycle {
+			options = append(options, "cycle")
+		} else {
+			options = append(options, "no cycle")
+		}
+	}
+	if r.cache != nil {
+		if *r.cache == 0 {
+			options = append(options, "no cache")
+		} else {
+			options = append(options, fmt.Sprintf("cache %d", *r.cache))
+		}
+	}
+
+	if len(options) == 0 {
+		return ""
+	}
+
+	return " " + strings.Join(options, " ")
+}
+
+// GetSequences returns the sequences of the schema, or of all the schemas if it's empty.
+func GetSequences(ctx context.Context, db Queryer, schema string) ([]Sequence, error) {
+	query := "select scm.name, seq.name, type_name(seq.user_type_id), " +
+		"cast(seq.start_value as bigint), cast(seq.increment as bigint), " +
+		"cast(seq.minimum_value as bigint), cast(seq.maximum_value as bigint), " +
+		"cast(isnull(seq.current_value, seq.start_value) as bigint), " +
+		"case when seq.is_cached = 0 then 0 else isnull(seq.cache_size, -1) end, seq.is_cycling " +
+		"from sys.sequences as seq join sys.schemas as scm on seq.schema_id = scm.schema_id"
+	var args []any
+	if schema != "" {
+		query += " where scm.name = @p1"
+		args = append(args, schema)
+	}
+	query += " order by scm.name, seq.name"
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get Sqlserver sequences error: %v", err)
+	}
+	defer rows.Close()
+
+	var sequences []Sequence
+	for rows.Next() {
+		var sequence Sequence
+		if err := rows.Scan(&sequence.Schema, &sequence.Name, &sequence.Type, &sequence.Start, &sequence.Increment,
+			&sequence.MinValue, &sequence.MaxValue, &sequence.Current, &sequence.CacheSize, &sequence.Cycle); err != nil {
+			return nil, fmt.Errorf("get Sqlserver sequences error: %v", err)
+		}
+		sequences = append(sequences, sequence)
+	}
+
+	return sequences, rows.Err()
+}
+
+// SequenceRange is a block of values reserved by ReserveSequenceRange.
+type SequenceRange struct {
+	First     int64
+	Last      int64
+	Increment int64
+	MinValue  int64
+	MaxValue  int64
+	// Cycles is the number of times the sequence cycled to reserve the range.
+	Cycles int64
+	Size   int64
+}
+
+// Values returns the values of the range in order, a cycling sequence restarts from its minimum value, or its
+// maximum value if it's descending, when it passes the other bound.
+func (r SequenceRange) Values() []int64 {
+	values := make([]int64, 0, r.Size)
+	value := r.First
+	for range r.Size {
+		values = append(values, value)
+
+		switch {
+		case r.Increment > 0 && value > r.MaxValue-r.Increment:
+			value = r.MinValue
+		case r.Increment < 0 && value < r.MinValue-r.Increment:
+			value = r.MaxValue
+		default:
+			value += r.Increment
+		}
+	}
+
+	return values
+}
+
+// ReserveSequenceRange reserves size values of the sequence with sp_sequence_get_range in one round trip, so the
+// ids of the rows can be assigned by the client before they are inserted. The sequence must have an integer type,
+// the table prefix of the connection is added to its name like Prefix does.
+func ReserveSequenceRange(ctx context.Context, db *sql.DB, name string, size int64) (SequenceRange, error) {
+	rows, err := db.QueryContext(ctx, "set nocount on; "+
+		"declare @first sql_variant, @last sql_variant, @increment sql_variant, @min sql_variant, @max sql_variant, @cycles int; "+
+		"exec sp_sequence_get_range @sequence_name = @p1, @range_size = @p2, "+
+		"@range_first_value = @first output, @range_last_value = @last output, @range_cycle_count = @cycles output, "+
+		"@sequence_increment = @increment output, @sequence_min_value = @min output, @sequence_max_value = @max output; "+
+		"select cast(@first as bigint), cast(@last as bigint), cast(@increment as bigint), "+
+		"cast(@min as bigint), cast(@max as bigint), cast(@cycles as bigint)",
+		quoteTable(tablePrefix(db), name), size)
+	if err != nil {
+		return SequenceRange{}, fmt.Errorf("reserve Sqlserver sequence range error: %v", err)
+	}
+	defer rows.Close()
+
+	sequenceRange := SequenceRange{Size: size}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return SequenceRange{}, fmt.Errorf("reserve Sqlserver sequence range error: %v", err)
+		}
+
+		return SequenceRange{}, fmt.Errorf("reserve Sqlserver sequence range error: %v", sql.ErrNoRows)
+	}
+	if err := rows.Scan(&sequenceRange.First, &sequenceRange.Last, &sequenceRange.Increment,
+		&sequenceRange.MinValue, &sequenceRange.MaxValue, &sequenceRange.Cycles); err != nil {
+		return SequenceRange{}, fmt.Errorf("reserve Sqlserver sequence range error: %v", err)
+	}
+
+	return sequenceRange, nil
+}
@@ -0,0 +1,77 @@
+package sqlserver
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSequenceDefinition(t *testing.T) {
+	sequence := NewSequence("sales.order_numbers")
+	assert.Equal(t, "create sequence [sales].[order_numbers] as bigint", sequence.CreateSql())
+	assert.Equal(t, "drop sequence if exists [sales].[order_numbers]", sequence.DropSql())
+
+	sequence.As("int").StartWith(1000).IncrementBy(10).MinValue(1).MaxValue(100000).Cycle(true).Cache(50)
+	assert.Equal(t, "create sequence [sales].[order_numbers] as int start with 1000 increment by 10 minvalue 1 maxvalue 100000 cycle cache 50", sequence.CreateSql())
+
+	assert.Equal(t, "alter sequence [invoices] restart with 1 no cycle no cache", NewSequence("invoices").StartWith(1).Cycle(false).Cache(0).AlterSql())
+
+	sequence = NewSequence("sales.order_numbers").Prefix("goravel_")
+	assert.Equal(t, "create sequence [sales].[goravel_order_numbers] as bigint", sequence.CreateSql())
+	assert.Equal(t, "alter sequence [sales].[goravel_order_numbers] increment by 5", sequence.IncrementBy(5).AlterSql())
+	assert.Equal(t, "drop sequence if exists [sales].[goravel_order_numbers]", sequence.DropSql())
+}
+
+func TestSequenceRangeValues(t *testing.T) {
+	tests := []struct {
+		name          string
+		sequenceRange SequenceRange
+		expect        []int64
+	}{
+		{
+			name:          "ascending",
+			sequenceRange: SequenceRange{First: 10, Last: 30, Increment: 10, MinValue: 1, MaxValue: 100, Size: 3},
+			expect:        []int64{10, 20, 30},
+		},
+		{
+			name:          "ascending with cycle",
+			sequenceRange: SequenceRange{First: 8, Last: 2, Increment: 2, MinValue: 0, MaxValue: 10, Cycles: 1, Size: 4},
+			expect:        []int64{8, 10, 0, 2},
+		},
+		{
+			name:          "descending with cycle",
+			sequenceRange: SequenceRange{First: 2, Last: 9, Increment: -1, MinValue: 1, MaxValue: 10, Cycles: 1, Size: 4},
+			expect:        []int64{2, 1, 10, 9},
+		},
+		{
+			name:          "empty",
+			sequenceRange: SequenceRange{},
+			expect:        []int64{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expect, test.sequenceRange.Values())
+		})
+	}
+}
+
+func TestSequenceQueries(t *testing.T) {
+	connector := &stubConnector{}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	sequences, err := GetSequences(context.Background(), db, "sales")
+	assert.NoError(t, err)
+	assert.Empty(t, sequences)
+
+	_, err = ReserveSequenceRange(context.Background(), db, "sales.order_numbers", 100)
+	assert.EqualError(t, err, "reserve Sqlserver sequence range error: sql: no rows in result set")
+
+	assert.Len(t, connector.queries, 2)
+	assert.Contains(t, connector.queries[0], "from sys.sequences as seq join sys.schemas as scm on seq.schema_id = scm.schema_id where scm.name = @p1")
+	assert.Contains(t, connector.queries[1], "exec sp_sequence_get_range @sequence_name = @p1, @range_size = @p2")
+}
@@ -0,0 +1,725 @@
+package sqlserver
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// DefaultGeographySRID is the SRID of the geography values without one, it's WGS 84.
+	DefaultGeographySRID = 4326
+
+	SpatialGeography = "geography"
+	SpatialGeometry  = "geometry"
+)
+
+var (
+	_ clause.Expression = Geography{}
+	_ gorm.Valuer       = Geography{}
+	_ driver.Valuer     = Geography{}
+	_ clause.Expression = Geometry{}
+	_ gorm.Valuer       = Geometry{}
+	_ driver.Valuer     = Geometry{}
+)
+
+// Shape is a spatial shape in well-known text, see Point, LineString, Polygon and the multi shapes. WKT holds the
+// shapes the Go types don't cover.
+type Shape interface {
+	WKT() string
+}
+
+// Point is a point, X is the longitude and Y is the latitude of a geography point.
+type Point struct {
+	X, Y float64
+}
+
+func (r Point) WKT() string {
+	return "POINT (" + r.coordinates() + ")"
+}
+
+func (r Point) coordinates() string {
+	return strconv.FormatFloat(r.X, 'f', -1, 64) + " " + strconv.FormatFloat(r.Y, 'f', -1, 64)
+}
+
+type LineString []Point
+
+func (r LineString) WKT() string {
+	return "LINESTRING " + r.text()
+}
+
+func (r LineString) text() string {
+	if len(r) == 0 {
+		return "EMPTY"
+	}
+
+	coordinates := make([]string, len(r))
+	for i, point := range r {
+		coordinates[i] = point.coordinates()
+	}
+
+	return "(" + strings.Join(coordinates, ", ") + ")"
+}
+
+// Polygon is a polygon, the first ring is the exterior ring and the others are the holes. The exterior ring of a
+// geography polygon is counter-clockwise.
+type Polygon []LineString
+
+func (r Polygon) WKT() string {
+	return "POLYGON " + r.text()
+}
+
+func (r Polygon) text() string {
+	if len(r) == 0 {
+		return "EMPTY"
+	}
+
+	rings := make([]string, len(r))
+	for i, ring := range r {
+		rings[i] = ring.text()
+	}
+
+	return "(" + strings.Join(rings, ", ") + ")"
+}
+
+type MultiPoint []Point
+
+func (r MultiPoint) WKT() string {
+	if len(r) == 0 {
+		return "MULTIPOINT EMPTY"
+	}
+
+	points := make([]string, len(r))
+	for i, point := range r {
+		points[i] = "(" + point.coordinates() + ")"
+	}
+
+	return "MULTIPOINT (" + strings.Join(points, ", ") + ")"
+}
+
+type MultiLineString []LineString
+
+func (r MultiLineString) WKT() string {
+	return "MULTILINESTRING " + Polygon(r).text()
+}
+
+type MultiPolygon []Polygon
+
+func (r MultiPolygon) WKT() string {
+	if len(r) == 0 {
+		return "MULTIPOLYGON EMPTY"
+	}
+
+	polygons := make([]string, len(r))
+	for i, polygon := range r {
+		polygons[i] = polygon.text()
+	}
+
+	return "MULTIPOLYGON (" + strings.Join(polygons, ", ") + ")"
+}
+
+// WKT is a shape in well-known text.
+type WKT string
+
+func (r WKT) WKT() string {
+	return string(r)
+}
+
+// Geography is a geography value. It's bound as geography::STGeomFromText in the queries, the inserts and the
+// updates, and it can be scanned from a geography column or from the well-known text returned by STAsText:
+//
+//	query.Where(sqlserver.STContains("zone", sqlserver.Geography{Shape: sqlserver.Point{X: -122.349, Y: 47.651}}))
+type Geography struct {
+	Shape Shape
+	// SRID is DefaultGeographySRID if it's 0.
+	SRID int
+}
+
+func (r Geography) Build(builder clause.Builder) {
+	buildSpatial(builder, SpatialGeography, r.Shape, r.srid())
+}
+
+func (r Geography) GormValue(_ context.Context, _ *gorm.DB) clause.Expr {
+	return spatialExpr(SpatialGeography, r.Shape, r.srid())
+}
+
+// Value returns the well-known text, SQL Server converts it to a geography with the SRID 4326.
+func (r Geography) Value() (driver.Value, error) {
+	if r.Shape == nil {
+		return nil, nil
+	}
+
+	return r.Shape.WKT(), nil
+}
+
+func (r *Geography) Scan(src any) error {
+	shape, srid, err := scanSpatial(src, true)
+	if err != nil {
+		return err
+	}
+
+	r.Shape, r.SRID = shape, srid
+
+	return nil
+}
+
+func (r Geography) srid() int {
+	if r.SRID == 0 {
+		return DefaultGeographySRID
+	}
+
+	return r.SRID
+}
+
+// Geometry is a geometry value in a planar coordinate system, see Geography.
+type Geometry struct {
+	Shape Shape
+	SRID  int
+}
+
+func (r Geometry) Build(builder clause.Builder) {
+	buildSpatial(builder, SpatialGeometry, r.Shape, r.SRID)
+}
+
+func (r Geometry) GormValue(_ context.Context, _ *gorm.DB) clause.Expr {
+	return spatialExpr(SpatialGeometry, r.Shape, r.SRID)
+}
+
+// Value returns the well-known text, SQL Server converts it to a geometry with the SRID 0.
+func (r Geometry) Value() (driver.Value, error) {
+	if r.Shape == nil {
+		return nil, nil
+	}
+
+	return r.Shape.WKT(), nil
+}
+
+func (r *Geometry) Scan(src any) error {
+	shape, srid, err := scanSpatial(src, false)
+	if err != nil {
+		return err
+	}
+
+	r.Shape, r.SRID = shape, srid
+
+	return nil
+}
+
+// STContains is true when the spatial column contains the value.
+func STContains(column string, value clause.Expression) clause.Expression {
+	return spatialCondition{column: column, method: "STContains", value: value}
+}
+
+// STIntersects is true when the spatial column intersects the value.
+func STIntersects(column string, value clause.Expression) clause.Expression {
+	return spatialCondition{column: column, method: "STIntersects", value: value}
+}
+
+// STDistance is the shortest distance between the spatial column and the value, in meters for the geography
+// values. It can be selected or used to order the rows:
+//
+//	query.Order(clause.OrderBy{Expression: sqlserver.STDistance("location", point)})
+func STDistance(column string, value clause.Expression) clause.Expression {
+	return spatialMethod{column: column, method: "STDistance", value: value}
+}
+
+// WithinDistance is true when the distance between the spatial column and the value is lower than or equal to
+// distance, it's sargable so a spatial index can be used.
+func WithinDistance(column string, value clause.Expression, distance float64) clause.Expression {
+	return clause.Expr{SQL: "? <= ?", Vars: []any{STDistance(column, value), distance}}
+}
+
+type spatialMethod struct {
+	column string
+	method string
+	value  clause.Expression
+}
+
+func (r spatialMethod) Build(builder clause.Builder) {
+	builder.WriteQuoted(r.column)
+	_, _ = builder.WriteString("." + r.method + "(")
+	r.value.Build(builder)
+	_ = builder.WriteByte(')')
+}
+
+type spatialCondition spatialMethod
+
+func (r spatialCondition) Build(builder clause.Builder) {
+	spatialMethod(r).Build(builder)
+	_, _ = builder.WriteString(" = 1")
+}
+
+func buildSpatial(builder clause.Builder, spatialType string, shape Shape, srid int) {
+	_, _ = builder.WriteString(spatialType + "::STGeomFromText(")
+	builder.AddVar(builder, shapeWKT(shape))
+	_, _ = builder.WriteString(", " + strconv.Itoa(srid) + ")")
+}
+
+func spatialExpr(spatialType string, shape Shape, srid int) clause.Expr {
+	return clause.Expr{SQL: fmt.Sprintf("%s::STGeomFromText(?, %d)", spatialType, srid), Vars: []any{shapeWKT(shape)}}
+}
+
+func shapeWKT(shape Shape) any {
+	if shape == nil {
+		return nil
+	}
+
+	return shape.WKT()
+}
+
+// scanSpatial scans the native serialization of a geography or a geometry value, or the well-known text.
+func scanSpatial(src any, geography bool) (Shape, int, error) {
+	switch src := src.(type) {
+	case nil:
+		return nil, 0, nil
+	case []byte:
+		return decodeSpatial(src, geography)
+	case string:
+		shape, err := ParseWKT(src)
+		return shape, 0, err
+	default:
+		return nil, 0, fmt.Errorf("unsupported Sqlserver spatial value %T", src)
+	}
+}
+
+// The properties and the shape types of the spatial serialization, see [MS-SSCLRT].
+const (
+	spatialHasZ        = 0x01
+	spatialHasM        = 0x02
+	spatialSinglePoint = 0x08
+	spatialSingleLine  = 0x10
+
+	spatialPoint           = 1
+	spatialLineString      = 2
+	spatialPolygon         = 3
+	spatialMultiPoint      = 4
+	spatialMultiLineString = 5
+	spatialMultiPolygon    = 6
+)
+
+var errInvalidSpatial = errors.New("invalid Sqlserver spatial value")
+
+type spatialShape struct {
+	parent int32
+	figure int32
+	kind   byte
+}
+
+type spatialValue struct {
+	points  []Point
+	figures []int32
+	shapes  []spatialShape
+}
+
+// decodeSpatial decodes the native serialization of a geography or a geometry value, the Z and M values are
+// dropped. The points of a geography value are stored as latitude and longitude.
+func decodeSpatial(data []byte, geography bool) (Shape, int, error) {
+	if len(data) < 6 {
+		return nil, 0, errInvalidSpatial
+	}
+
+	srid := int(int32(binary.LittleEndian.Uint32(data)))
+	properties := data[5]
+	reader := &spatialReader{data: data[6:], geography: geography}
+
+	var value spatialValue
+	switch {
+	case properties&spatialSinglePoint != 0:
+		value.points = reader.points(1)
+		value.figures = []int32{0}
+		value.shapes = []spatialShape{{parent: -1, figure: 0, kind: spatialPoint}}
+	case properties&spatialSingleLine != 0:
+		value.points = reader.points(2)
+		value.figures = []int32{0}
+		value.shapes = []spatialShape{{parent: -1, figure: 0, kind: spatialLineString}}
+	default:
+		value.points = reader.points(int(reader.int32()))
+		if properties&spatialHasZ != 0 {
+			reader.skip(8 * len(value.points))
+		}
+		if properties&spatialHasM != 0 {
+			reader.skip(8 * len(value.points))
+		}
+		figures := int(reader.int32())
+		for range figures {
+			reader.skip(1)
+			value.figures = append(value.figures, reader.int32())
+		}
+		shapes := int(reader.int32())
+		for range shapes {
+			value.shapes = append(value.shapes, spatialShape{parent: reader.int32(), figure: reader.int32(), kind: reader.byte()})
+		}
+	}
+
+	if reader.err != nil {
+		return nil, 0, reader.err
+	}
+	if len(value.shapes) == 0 {
+		return nil, srid, nil
+	}
+	// A shape is always preceded by its parent, which also rules out the cycles.
+	for i, shape := range value.shapes {
+		if int(shape.parent) >= i {
+			return nil, 0, errInvalidSpatial
+		}
+	}
+
+	shape, err := value.shape(0)
+
+	return shape, srid, err
+}
+
+func (r spatialValue) shape(index int) (Shape, error) {
+	figures := r.shapeFigures(index)
+	switch r.shapes[index].kind {
+	case spatialPoint:
+		if len(figures) == 0 {
+			return WKT("POINT EMPTY"), nil
+		}
+		points, err := r.figure(figures[0])
+		if err != nil {
+			return nil, err
+		}
+		if len(points) == 0 {
+			return nil, errInvalidSpatial
+		}
+		return points[0], nil
+	case spatialLineString:
+		if len(figures) == 0 {
+			return LineString{}, nil
+		}
+		return r.figure(figures[0])
+	case spatialPolygon:
+		polygon := Polygon{}
+		for _, figure := range figures {
+			ring, err := r.figure(figure)
+			if err != nil {
+				return nil, err
+			}
+			polygon = append(polygon, ring)
+		}
+		return polygon, nil
+	case spatialMultiPoint, spatialMultiLineString, spatialMultiPolygon:
+		var children []Shape
+		for i, shape := range r.shapes {
+			if int(shape.parent) == index {
+				child, err := r.shape(i)
+				if err != nil {
+					return nil, err
+				}
+				children = append(children, child)
+			}
+		}
+
+		return multiShape(r.shapes[index].kind, children), nil
+	default:
+		return nil, fmt.Errorf("unsupported Sqlserver spatial shape type %d", r.shapes[index].kind)
+	}
+}
+
+// shapeFigures returns the indexes of the figures of a shape, they end at the figure of the next shape that has
+// one.
+func (r spatialValue) shapeFigures(index int) []int {
+	start := r.shapes[index].figure
+	if start < 0 {
+		return nil
+	}
+
+	end := int32(len(r.figures))
+	for _, shape := range r.shapes[index+1:] {
+		if shape.figure >= 0 {
+			end = shape.figure
+			break
+		}
+	}
+
+	var figures []int
+	for figure := start; figure < end && int(figure) < len(r.figures); figure++ {
+		figures = append(figures, int(figure))
+	}
+
+	return figures
+}
+
+// figure returns the points of a figure, they end at the point of the next figure.
+func (r spatialValue) figure(index int) (LineString, error) {
+	start := int(r.figures[index])
+	end := len(r.points)
+	if index+1 < len(r.figures) {
+		end = int(r.figures[index+1])
+	}
+	if start < 0 || start > end || end > len(r.points) {
+		return nil, errInvalidSpatial
+	}
+
+	return append(LineString{}, r.points[start:end]...), nil
+}
+
+func multiShape(kind byte, children []Shape) Shape {
+	switch kind {
+	case spatialMultiPoint:
+		multi := MultiPoint{}
+		for _, child := range children {
+			if point, ok := child.(Point); ok {
+				multi = append(multi, point)
+			}
+		}
+		return multi
+	case spatialMultiLineString:
+		multi := MultiLineString{}
+		for _, child := range children {
+			if line, ok := child.(LineString); ok {
+				multi = append(multi, line)
+			}
+		}
+		return multi
+	default:
+		multi := MultiPolygon{}
+		for _, child := range children {
+			if polygon, ok := child.(Polygon); ok {
+				multi = append(multi, polygon)
+			}
+		}
+		return multi
+	}
+}
+
+type spatialReader struct {
+	data      []byte
+	geography bool
+	err       error
+}
+
+func (r *spatialReader) read(size int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if size < 0 || len(r.data) < size {
+		r.err = errInvalidSpatial
+		return nil
+	}
+
+	data := r.data[:size]
+	r.data = r.data[size:]
+
+	return data
+}
+
+func (r *spatialReader) skip(size int) {
+	r.read(size)
+}
+
+func (r *spatialReader) byte() byte {
+	if data := r.read(1); data != nil {
+		return data[0]
+	}
+
+	return 0
+}
+
+func (r *spatialReader) int32() int32 {
+	if data := r.read(4); data != nil {
+		return int32(binary.LittleEndian.Uint32(data))
+	}
+
+	return 0
+}
+
+func (r *spatialReader) float64() float64 {
+	if data := r.read(8); data != nil {
+		return math.Float64frombits(binary.LittleEndian.Uint64(data))
+	}
+
+	return 0
+}
+
+func (r *spatialReader) points(count int) []Point {
+	if count < 0 || count*16 > len(r.data) {
+		r.err = errInvalidSpatial
+		return nil
+	}
+
+	points := make([]Point, count)
+	for i := range points {
+		first, second := r.float64(), r.float64()
+		if r.geography {
+			points[i] = Point{X: second, Y: first}
+		} else {
+			points[i] = Point{X: first, Y: second}
+		}
+	}
+
+	return points
+}
+
+// ParseWKT parses the well-known text of a point, a line string, a polygon or a multi shape, the other shapes are
+// returned as WKT. The Z and M values are dropped.
+func ParseWKT(text string) (Shape, error) {
+	text = strings.TrimSpace(text)
+	index := strings.IndexAny(text, " (")
+	if index < 0 {
+		return WKT(text), nil
+	}
+
+	kind := strings.ToUpper(text[:index])
+	body := strings.TrimSpace(text[index:])
+	if strings.EqualFold(body, "EMPTY") {
+		switch kind {
+		case "LINESTRING":
+			return LineString{}, nil
+		case "POLYGON":
+			return Polygon{}, nil
+		case "MULTIPOINT":
+			return MultiPoint{}, nil
+		case "MULTILINESTRING":
+			return MultiLineString{}, nil
+		case "MULTIPOLYGON":
+			return MultiPolygon{}, nil
+		default:
+			return WKT(text), nil
+		}
+	}
+
+	parser := &wktParser{text: body}
+	var shape Shape
+	switch kind {
+	case "POINT":
+		points := parser.points()
+		if len(points) != 1 {
+			parser.fail()
+			break
+		}
+		shape = points[0]
+	case "LINESTRING":
+		shape = parser.points()
+	case "POLYGON":
+		shape = parser.polygon()
+	case "MULTIPOINT":
+		shape = MultiPoint(parser.multiPoint())
+	case "MULTILINESTRING":
+		shape = MultiLineString(parser.polygon())
+	case "MULTIPOLYGON":
+		var multi MultiPolygon
+		parser.list(func() {
+			multi = append(multi, parser.polygon())
+		})
+		shape = multi
+	default:
+		return WKT(text), nil
+	}
+
+	if parser.err == nil && strings.TrimSpace(parser.text) != "" {
+		parser.fail()
+	}
+	if parser.err != nil {
+		return nil, parser.err
+	}
+
+	return shape, nil
+}
+
+type wktParser struct {
+	text string
+	err  error
+}
+
+func (r *wktParser) fail() {
+	if r.err == nil {
+		r.err = fmt.Errorf("invalid well-known text near %q", r.text)
+	}
+}
+
+func (r *wktParser) consume(char byte) bool {
+	r.text = strings.TrimSpace(r.text)
+	if r.err != nil || len(r.text) == 0 || r.text[0] != char {
+		return false
+	}
+	r.text = r.text[1:]
+
+	return true
+}
+
+// list parses a parenthesized, comma separated list of items.
+func (r *wktParser) list(item func()) {
+	if !r.consume('(') {
+		r.fail()
+		return
+	}
+	for r.err == nil {
+		item()
+		if r.consume(',') {
+			continue
+		}
+		if !r.consume(')') {
+			r.fail()
+		}
+		return
+	}
+}
+
+func (r *wktParser) points() LineString {
+	var points LineString
+	r.list(func() {
+		points = append(points, r.point())
+	})
+
+	return points
+}
+
+func (r *wktParser) polygon() Polygon {
+	var polygon Polygon
+	r.list(func() {
+		polygon = append(polygon, r.points())
+	})
+
+	return polygon
+}
+
+// multiPoint parses the points of a multipoint, they may be parenthesized or not.
+func (r *wktParser) multiPoint() []Point {
+	var points []Point
+	r.list(func() {
+		if r.consume('(') {
+			points = append(points, r.point())
+			if !r.consume(')') {
+				r.fail()
+			}
+			return
+		}
+		points = append(points, r.point())
+	})
+
+	return points
+}
+
+func (r *wktParser) point() Point {
+	r.text = strings.TrimSpace(r.text)
+	end := strings.IndexAny(r.text, ",)")
+	if end < 0 {
+		r.fail()
+		return Point{}
+	}
+
+	fields := strings.Fields(r.text[:end])
+	if len(fields) < 2 || len(fields) > 4 {
+		r.fail()
+		return Point{}
+	}
+	x, errX := strconv.ParseFloat(fields[0], 64)
+	y, errY := strconv.ParseFloat(fields[1], 64)
+	if errX != nil || errY != nil {
+		r.fail()
+		return Point{}
+	}
+	r.text = r.text[end:]
+
+	return Point{X: x, Y: y}
+}
@@ -2,6 +2,8 @@ package sqlserver
 
 import (
 	"fmt"
+	"log/slog"
+	"regexp"
 
 	"github.com/goravel/framework/contracts/config"
 	"github.com/goravel/framework/contracts/database"
@@ -17,9 +19,12 @@ import (
 
 var _ driver.Driver = &Sqlserver{}
 
+var collationRegexp = regexp.MustCompile(`_(CI|CS|BIN|BIN2)(_|$)`)
+
 type Sqlserver struct {
 	config contracts.ConfigBuilder
 	log    log.Log
+	logger *slog.Logger
 }
 
 func NewSqlserver(config config.Config, log log.Log, connection string) *Sqlserver {
@@ -29,23 +34,55 @@ func NewSqlserver(config config.Config, log log.Log, connection string) *Sqlserv
 	}
 }
 
+// NewSqlserverWithConfig builds the driver from a ConfigBuilder without a goravel Application,
+// slog.Default() is used if the logger is nil.
+func NewSqlserverWithConfig(config contracts.ConfigBuilder, logger *slog.Logger) *Sqlserver {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &Sqlserver{
+		config: config,
+		logger: logger,
+	}
+}
+
+// NewStandaloneSqlserver builds the driver from a single writer config, it's useful for CLI tools and tests.
+func NewStandaloneSqlserver(fullConfig contracts.FullConfig, logger *slog.Logger) *Sqlserver {
+	return NewSqlserverWithConfig(NewStaticConfig([]contracts.FullConfig{fullConfig}, nil), logger)
+}
+
 func (r *Sqlserver) Docker() (docker.DatabaseDriver, error) {
 	writers := r.config.Writers()
 	if len(writers) == 0 {
 		return nil, errors.DatabaseConfigNotFound
 	}
+	if config, ok := r.config.(*Config); ok {
+		if _, err := config.dockerConfig(); err != nil {
+			return nil, err
+		}
+	}
 
-	return NewDocker(r.config, writers[0].Database, writers[0].Username, writers[0].Password), nil
+	return NewDocker(r.config, writers[0].Database, writers[0].Username, writers[0].Password).
+		Configure(writers[0].Docker).
+		Readers(r.config.Readers()...), nil
 }
 
 func (r *Sqlserver) Grammar() driver.Grammar {
-	return NewGrammar(r.config.Writers()[0].Prefix)
+	writer := r.config.Writers()[0]
+
+	return NewGrammar(writer.Prefix).SetUnicode(writer.Unicode, collation(writer.Charset))
+}
+
+// Metrics returns a collector of the pool stats and server wait stats of the connection.
+func (r *Sqlserver) Metrics(registry MetricsRegistry, options MetricsOptions) *MetricsCollector {
+	return NewMetricsCollector(r.config.Connection(), registry, options)
 }
 
 func (r *Sqlserver) Pool() database.Pool {
 	return database.Pool{
-		Readers: r.fullConfigsToConfigs(r.config.Readers()),
-		Writers: r.fullConfigsToConfigs(r.config.Writers()),
+		Readers: r.fullConfigsToConfigs(r.config.Readers(), RoleReader),
+		Writers: r.fullConfigsToConfigs(r.config.Writers(), RoleWriter),
 	}
 }
 
@@ -53,15 +90,19 @@ func (r *Sqlserver) Processor() driver.Processor {
 	return NewProcessor()
 }
 
-func (r *Sqlserver) fullConfigsToConfigs(fullConfigs []contracts.FullConfig) []database.Config {
+func (r *Sqlserver) fullConfigsToConfigs(fullConfigs []contracts.FullConfig, role string) []database.Config {
 	configs := make([]database.Config, len(fullConfigs))
 	for i, fullConfig := range fullConfigs {
+		dialector := fullConfigToDialector(fullConfig, role, i)
+		if dialector == nil {
+			r.warnf("the Sqlserver %s %d of the connection %s has no dsn or host, it can't be connected", role, i, fullConfig.Connection)
+		}
 		configs[i] = database.Config{
 			Charset:      fullConfig.Charset,
 			Connection:   fullConfig.Connection,
 			Dsn:          fullConfig.Dsn,
 			Database:     fullConfig.Database,
-			Dialector:    fullConfigToDialector(fullConfig),
+			Dialector:    dialector,
 			Driver:       Name,
 			Host:         fullConfig.Host,
 			NameReplacer: fullConfig.NameReplacer,
@@ -78,6 +119,15 @@ func (r *Sqlserver) fullConfigsToConfigs(fullConfigs []contracts.FullConfig) []d
 	return configs
 }
 
+// warnf logs a warning by the goravel log, or by the slog logger of a driver built without a goravel Application.
+func (r *Sqlserver) warnf(format string, args ...any) {
+	if r.log != nil {
+		r.log.Warningf(format, args...)
+	} else if r.logger != nil {
+		r.logger.Warn(fmt.Sprintf(format, args...))
+	}
+}
+
 func dsn(fullConfig contracts.FullConfig) string {
 	if fullConfig.Dsn != "" {
 		return fullConfig.Dsn
@@ -86,17 +136,32 @@ func dsn(fullConfig contracts.FullConfig) string {
 		return ""
 	}
 
-	return fmt.Sprintf("sqlserver://%s:%s@%s:%d?database=%s&charset=%s&timezone=%s&MultipleActiveResultSets=true",
-		fullConfig.Username, fullConfig.Password, fullConfig.Host, fullConfig.Port, fullConfig.Database, fullConfig.Charset, fullConfig.Timezone)
+	return fmt.Sprintf("sqlserver://%s:%s@%s:%d?database=%s&timezone=%s&MultipleActiveResultSets=true",
+		fullConfig.Username, fullConfig.Password, fullConfig.Host, fullConfig.Port, fullConfig.Database, fullConfig.Timezone)
 }
 
-func fullConfigToDialector(fullConfig contracts.FullConfig) gorm.Dialector {
+// collation returns the charset if it's a SQL Server collation (Latin1_General_100_CI_AS_SC_UTF8, for example),
+// the MySQL style charsets (utf8mb4) are ignored, SQL Server has no connection charset.
+func collation(charset string) string {
+	if collationRegexp.MatchString(charset) {
+		return charset
+	}
+
+	return ""
+}
+
+func fullConfigToDialector(fullConfig contracts.FullConfig, role string, index int) gorm.Dialector {
 	dsn := dsn(fullConfig)
 	if dsn == "" {
 		return nil
 	}
 
-	return sqlserver.New(sqlserver.Config{
-		DSN: dsn,
-	})
+	return &Dialector{
+		Dialector: &sqlserver.Dialector{Config: &sqlserver.Config{
+			DSN: dsn,
+		}},
+		fullConfig: fullConfig,
+		role:       role,
+		index:      index,
+	}
 }
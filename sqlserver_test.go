@@ -0,0 +1,54 @@
+package sqlserver
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/goravel/sqlserver/contracts"
+)
+
+func TestNewStandaloneSqlserver(t *testing.T) {
+	driver := NewStandaloneSqlserver(contracts.FullConfig{
+		Config: contracts.Config{
+			Host:     "127.0.0.1",
+			Port:     1433,
+			Database: "goravel",
+			Username: "sa",
+			Password: "Framework!123",
+		},
+		Connection: "default",
+		Prefix:     "goravel_",
+	}, nil)
+
+	assert.NotNil(t, driver.logger)
+	assert.NotNil(t, driver.Grammar())
+	assert.NotNil(t, driver.Processor())
+
+	pool := driver.Pool()
+	assert.Empty(t, pool.Readers)
+	assert.Len(t, pool.Writers, 1)
+	assert.Equal(t, "default", pool.Writers[0].Connection)
+	assert.Equal(t, "goravel_", pool.Writers[0].Prefix)
+	assert.NotNil(t, pool.Writers[0].Dialector)
+
+	docker, err := driver.Docker()
+	assert.NoError(t, err)
+	assert.Equal(t, "goravel", docker.Config().Database)
+}
+
+func TestPoolWarnsWithoutHost(t *testing.T) {
+	var buffer bytes.Buffer
+	driver := NewStandaloneSqlserver(contracts.FullConfig{
+		Config:     contracts.Config{Database: "goravel"},
+		Connection: "default",
+	}, slog.New(slog.NewTextHandler(&buffer, nil)))
+
+	pool := driver.Pool()
+	assert.Len(t, pool.Writers, 1)
+	assert.Nil(t, pool.Writers[0].Dialector)
+	assert.Contains(t, buffer.String(), "level=WARN")
+	assert.Contains(t, buffer.String(), "the Sqlserver writer 0 of the connection default has no dsn or host")
+}
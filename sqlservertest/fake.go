@@ -0,0 +1,428 @@
+// Package sqlservertest provides in-process fakes of SQL Server for the unit tests, they record every
+// statement and return the scripted results, no container is required. Fake works at the database/sql
+// level, Server speaks the TDS protocol to the real go-mssqldb client.
+package sqlservertest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"regexp"
+	"sync"
+
+	contractsdatabase "github.com/goravel/framework/contracts/database"
+	contractsdriver "github.com/goravel/framework/contracts/database/driver"
+	"github.com/goravel/framework/contracts/testing/docker"
+	mssql "github.com/microsoft/go-mssqldb"
+	gormsqlserver "gorm.io/driver/sqlserver"
+	"gorm.io/gorm"
+
+	"github.com/goravel/sqlserver"
+)
+
+const (
+	StatementBegin    = "BEGIN TRANSACTION"
+	StatementCommit   = "COMMIT"
+	StatementRollback = "ROLLBACK"
+)
+
+var (
+	_ contractsdriver.Driver   = &Fake{}
+	_ driver.Connector         = &connector{}
+	_ driver.ConnBeginTx       = &conn{}
+	_ driver.ExecerContext     = &conn{}
+	_ driver.NamedValueChecker = &conn{}
+	_ driver.QueryerContext    = &conn{}
+)
+
+// Statement is a statement received by the fake.
+type Statement struct {
+	SQL string
+	// Args are the values of the parameters, the named parameters are sql.NamedArg.
+	Args []any
+}
+
+// Fake is a fake SQL Server, it implements the goravel database driver, so it can be used in the via of a connection:
+//
+//	"via": func() (driver.Driver, error) { return fake, nil },
+type Fake struct {
+	recorder
+	db     *sql.DB
+	prefix string
+}
+
+func New() *Fake {
+	fake := &Fake{}
+	fake.db = sql.OpenDB(&connector{fake: fake})
+
+	return fake
+}
+
+// WithPrefix sets the table prefix used by Grammar and Pool.
+func (r *Fake) WithPrefix(prefix string) *Fake {
+	r.prefix = prefix
+
+	return r
+}
+
+// DB returns a database/sql handle of the fake.
+func (r *Fake) DB() *sql.DB {
+	return r.db
+}
+
+// Dialector returns a gorm SQL Server dialector of the fake.
+func (r *Fake) Dialector() gorm.Dialector {
+	return gormsqlserver.New(gormsqlserver.Config{Conn: r.db})
+}
+
+func (r *Fake) Docker() (docker.DatabaseDriver, error) {
+	return nil, fmt.Errorf("the fake of %s doesn't support Docker", sqlserver.Name)
+}
+
+func (r *Fake) Grammar() contractsdriver.Grammar {
+	return sqlserver.NewGrammar(r.prefix)
+}
+
+func (r *Fake) Pool() contractsdatabase.Pool {
+	return contractsdatabase.Pool{
+		Writers: []contractsdatabase.Config{
+			{
+				Connection: "sqlserver",
+				Database:   "fake",
+				Dialector:  r.Dialector(),
+				Driver:     sqlserver.Name,
+				Prefix:     r.prefix,
+			},
+		},
+	}
+}
+
+func (r *Fake) Processor() contractsdriver.Processor {
+	return sqlserver.NewProcessor()
+}
+
+func (r *Fake) record(query string, args []driver.NamedValue) *Expectation {
+	statement := Statement{SQL: query}
+	for _, arg := range args {
+		if arg.Name != "" {
+			statement.Args = append(statement.Args, sql.Named(arg.Name, arg.Value))
+		} else {
+			statement.Args = append(statement.Args, arg.Value)
+		}
+	}
+
+	return r.recorder.record(statement)
+}
+
+// recorder records the statements and matches them with the expectations, it's shared by Fake and Server.
+type recorder struct {
+	expectations []*Expectation
+	lock         sync.Mutex
+	statements   []Statement
+}
+
+// Expect scripts the result of the statements matching the regular expression, the expectations are
+// matched in the order they are added.
+func (r *recorder) Expect(pattern string) *Expectation {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	expectation := &Expectation{pattern: regexp.MustCompile(pattern)}
+	r.expectations = append(r.expectations, expectation)
+
+	return expectation
+}
+
+// Statements returns the statements received.
+func (r *recorder) Statements() []Statement {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return append([]Statement(nil), r.statements...)
+}
+
+// SQL returns the SQL of the statements received.
+func (r *recorder) SQL() []string {
+	statements := r.Statements()
+	sqls := make([]string, len(statements))
+	for i, statement := range statements {
+		sqls[i] = statement.SQL
+	}
+
+	return sqls
+}
+
+// Reset removes the statements and the expectations.
+func (r *recorder) Reset() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.expectations = nil
+	r.statements = nil
+}
+
+func (r *recorder) record(statement Statement) *Expectation {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.statements = append(r.statements, statement)
+	for _, expectation := range r.expectations {
+		if expectation.match(statement.SQL) {
+			return expectation
+		}
+	}
+
+	return nil
+}
+
+// Expectation is the scripted result of the matching statements.
+type Expectation struct {
+	columns      []string
+	disconnect   bool
+	err          error
+	lastInsertID int64
+	messages     []string
+	pattern      *regexp.Regexp
+	rows         [][]any
+	rowsAffected int64
+	times        int
+}
+
+// WillReturnRows returns rows to the queries.
+func (r *Expectation) WillReturnRows(columns []string, rows ...[]any) *Expectation {
+	r.columns = columns
+	r.rows = rows
+
+	return r
+}
+
+// WillReturnResult returns a result to the executions.
+func (r *Expectation) WillReturnResult(lastInsertID, rowsAffected int64) *Expectation {
+	r.lastInsertID = lastInsertID
+	r.rowsAffected = rowsAffected
+
+	return r
+}
+
+// WillReturnError returns a SQL Server error, for example 2627 for a violation of a unique constraint.
+func (r *Expectation) WillReturnError(number int32, message string) *Expectation {
+	return r.WillReturnErr(mssql.Error{Number: number, Class: 16, Message: message})
+}
+
+// WillReturnErr returns an error to the matching statements.
+func (r *Expectation) WillReturnErr(err error) *Expectation {
+	r.err = err
+
+	return r
+}
+
+// WillReturnMessages sends informational messages, like PRINT does, before the result. Only Server sends them.
+func (r *Expectation) WillReturnMessages(messages ...string) *Expectation {
+	r.messages = messages
+
+	return r
+}
+
+// WillDisconnect drops the connection instead of returning a result, Server closes the socket and
+// Fake returns driver.ErrBadConn, database/sql retries the statement on another connection in both cases.
+func (r *Expectation) WillDisconnect() *Expectation {
+	r.disconnect = true
+
+	return r
+}
+
+// Times limits the number of matches, the expectation matches forever by default.
+func (r *Expectation) Times(times int) *Expectation {
+	r.times = times
+
+	return r
+}
+
+// Once limits the expectation to one match.
+func (r *Expectation) Once() *Expectation {
+	return r.Times(1)
+}
+
+func (r *Expectation) error() error {
+	if r.disconnect {
+		return driver.ErrBadConn
+	}
+
+	return r.err
+}
+
+func (r *Expectation) match(query string) bool {
+	if r.times < 0 || !r.pattern.MatchString(query) {
+		return false
+	}
+	if r.times > 0 {
+		if r.times--; r.times == 0 {
+			r.times = -1
+		}
+	}
+
+	return true
+}
+
+type connector struct {
+	fake *Fake
+}
+
+func (r *connector) Connect(_ context.Context) (driver.Conn, error) {
+	return &conn{fake: r.fake}, nil
+}
+
+func (r *connector) Driver() driver.Driver {
+	return &mssql.Driver{}
+}
+
+type conn struct {
+	fake *Fake
+}
+
+func (r *conn) Begin() (driver.Tx, error) {
+	return r.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (r *conn) BeginTx(_ context.Context, _ driver.TxOptions) (driver.Tx, error) {
+	if expectation := r.fake.record(StatementBegin, nil); expectation != nil {
+		if err := expectation.error(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &tx{fake: r.fake}, nil
+}
+
+// CheckNamedValue accepts every value, so the go-mssqldb types (mssql.VarChar, for example) are recorded as they are.
+func (r *conn) CheckNamedValue(_ *driver.NamedValue) error {
+	return nil
+}
+
+func (r *conn) Close() error {
+	return nil
+}
+
+func (r *conn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	expectation := r.fake.record(query, args)
+	if expectation == nil {
+		return result{}, nil
+	}
+	if err := expectation.error(); err != nil {
+		return nil, err
+	}
+
+	return result{lastInsertID: expectation.lastInsertID, rowsAffected: expectation.rowsAffected}, nil
+}
+
+func (r *conn) Prepare(query string) (driver.Stmt, error) {
+	return &stmt{conn: r, query: query}, nil
+}
+
+func (r *conn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	expectation := r.fake.record(query, args)
+	if expectation == nil {
+		return &rows{}, nil
+	}
+	if err := expectation.error(); err != nil {
+		return nil, err
+	}
+
+	return &rows{columns: expectation.columns, values: expectation.rows}, nil
+}
+
+type stmt struct {
+	conn  *conn
+	query string
+}
+
+func (r *stmt) Close() error {
+	return nil
+}
+
+func (r *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return r.conn.ExecContext(context.Background(), r.query, valuesToNamedValues(args))
+}
+
+func (r *stmt) NumInput() int {
+	return -1
+}
+
+func (r *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return r.conn.QueryContext(context.Background(), r.query, valuesToNamedValues(args))
+}
+
+type tx struct {
+	fake *Fake
+}
+
+func (r *tx) Commit() error {
+	if expectation := r.fake.record(StatementCommit, nil); expectation != nil {
+		return expectation.error()
+	}
+
+	return nil
+}
+
+func (r *tx) Rollback() error {
+	if expectation := r.fake.record(StatementRollback, nil); expectation != nil {
+		return expectation.error()
+	}
+
+	return nil
+}
+
+type result struct {
+	lastInsertID int64
+	rowsAffected int64
+}
+
+func (r result) LastInsertId() (int64, error) {
+	return r.lastInsertID, nil
+}
+
+func (r result) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
+
+type rows struct {
+	columns []string
+	values  [][]any
+	index   int
+}
+
+func (r *rows) Close() error {
+	return nil
+}
+
+func (r *rows) Columns() []string {
+	return r.columns
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.index >= len(r.values) {
+		return io.EOF
+	}
+
+	for i, value := range r.values[r.index] {
+		converted, err := driver.DefaultParameterConverter.ConvertValue(value)
+		if err != nil {
+			return err
+		}
+		dest[i] = converted
+	}
+	r.index++
+
+	return nil
+}
+
+func valuesToNamedValues(args []driver.Value) []driver.NamedValue {
+	values := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		values[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+
+	return values
+}
@@ -0,0 +1,65 @@
+package sqlservertest
+
+import (
+	"errors"
+	"testing"
+
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type User struct {
+	ID   uint
+	Name string
+}
+
+func TestFake(t *testing.T) {
+	fake := New()
+	db, err := gorm.Open(fake.Dialector(), &gorm.Config{Logger: logger.Discard, SkipDefaultTransaction: true})
+	require.NoError(t, err)
+
+	fake.Expect(`FROM "users"`).WillReturnRows([]string{"id", "name"}, []any{1, "goravel"}, []any{2, "framework"})
+	var users []User
+	assert.NoError(t, db.Where("name <> ?", "admin").Find(&users).Error)
+	assert.Equal(t, []User{{ID: 1, Name: "goravel"}, {ID: 2, Name: "framework"}}, users)
+
+	fake.Expect(`^UPDATE`).WillReturnResult(0, 2).Once()
+	result := db.Model(&User{}).Where("id > ?", 0).Update("name", "goravel")
+	assert.NoError(t, result.Error)
+	assert.Equal(t, int64(2), result.RowsAffected)
+
+	fake.Expect(`^INSERT`).WillReturnError(2627, "Violation of UNIQUE KEY constraint")
+	err = db.Exec("INSERT INTO users (name) VALUES (?)", "goravel").Error
+	var sqlErr mssql.Error
+	assert.True(t, errors.As(err, &sqlErr))
+	assert.Equal(t, int32(2627), sqlErr.Number)
+
+	assert.Equal(t, []Statement{
+		{SQL: `SELECT * FROM "users" WHERE name <> @p1`, Args: []any{"admin"}},
+		{SQL: `UPDATE "users" SET "name"=@p1 WHERE id > @p2`, Args: []any{"goravel", 0}},
+		{SQL: `INSERT INTO users (name) VALUES (@p1)`, Args: []any{"goravel"}},
+	}, fake.Statements())
+
+	fake.Reset()
+	assert.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+		return tx.Exec("DELETE FROM users").Error
+	}))
+	assert.Equal(t, []string{StatementBegin, "DELETE FROM users", StatementCommit}, fake.SQL())
+}
+
+func TestFakeDriver(t *testing.T) {
+	fake := New().WithPrefix("goravel_")
+
+	pool := fake.Pool()
+	assert.Len(t, pool.Writers, 1)
+	assert.Equal(t, "goravel_", pool.Writers[0].Prefix)
+	assert.Equal(t, "sqlserver", pool.Writers[0].Dialector.Name())
+
+	assert.Contains(t, fake.Grammar().CompileTables(""), "sys.tables")
+
+	_, err := fake.Docker()
+	assert.Error(t, err)
+}
@@ -0,0 +1,532 @@
+package sqlservertest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"database/sql/driver"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	mssql "github.com/microsoft/go-mssqldb"
+
+	"github.com/goravel/sqlserver/contracts"
+)
+
+const (
+	defaultDatabase = "master"
+	defaultUsername = "sa"
+
+	loginFailedErrorNumber = 18456
+	userErrorNumber        = 50000
+)
+
+var errDisconnected = errors.New("disconnected by the expectation")
+
+// Login is a login received by Server.
+type Login struct {
+	Username string
+	Password string
+	Database string
+	AppName  string
+	Hostname string
+	// ReadOnly is true when the connection string has ApplicationIntent=ReadOnly.
+	ReadOnly bool
+	// Encrypted is true when the login was sent over TLS.
+	Encrypted bool
+}
+
+// Server is an in-process stand-in of SQL Server speaking the TDS protocol, so the real go-mssqldb client,
+// its DSN, TLS and login handling can be tested without a container. It answers PRELOGIN and LOGIN7, records
+// the SQL batches, the sp_executesql calls and the transaction requests as statements, and replies with the
+// scripted results of Expect, including the error tokens, the informational messages and the disconnections.
+type Server struct {
+	recorder
+	closed            bool
+	conns             map[net.Conn]struct{}
+	listener          net.Listener
+	loginExpectations []*Expectation
+	logins            []Login
+	password          string
+	tlsConfig         *tls.Config
+	username          string
+	wait              sync.WaitGroup
+}
+
+func NewServer() *Server {
+	return &Server{
+		conns: make(map[net.Conn]struct{}),
+	}
+}
+
+// WithLogin sets the only accepted credentials, the other logins fail with the error 18456. Every login is
+// accepted by default.
+func (r *Server) WithLogin(username, password string) *Server {
+	r.username = username
+	r.password = password
+
+	return r
+}
+
+// WithTLS enables the encryption, a self-signed certificate of 127.0.0.1 is generated when the config is nil.
+// The client must trust it: TrustServerCertificate=true is added to DSN.
+func (r *Server) WithTLS(config *tls.Config) *Server {
+	if config == nil {
+		config = &tls.Config{}
+	}
+	r.tlsConfig = config.Clone()
+
+	return r
+}
+
+// Start listens on a random port of 127.0.0.1.
+func (r *Server) Start() error {
+	if r.tlsConfig != nil {
+		if len(r.tlsConfig.Certificates) == 0 && r.tlsConfig.GetCertificate == nil {
+			certificate, err := selfSignedCertificate()
+			if err != nil {
+				return fmt.Errorf("generate certificate of Sqlserver error: %v", err)
+			}
+			r.tlsConfig.Certificates = []tls.Certificate{certificate}
+		}
+		// go-mssqldb wraps the handshake in TDS packets and only sends its last flight when it reads the
+		// reply, TLS 1.3 has no reply to the last flight of the client.
+		r.tlsConfig.MaxVersion = tls.VersionTLS12
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	r.listener = listener
+
+	r.wait.Add(1)
+	go r.accept()
+
+	return nil
+}
+
+// Close stops listening and drops the open connections.
+func (r *Server) Close() error {
+	r.lock.Lock()
+	r.closed = true
+	for conn := range r.conns {
+		_ = conn.Close()
+	}
+	r.lock.Unlock()
+
+	var err error
+	if r.listener != nil {
+		err = r.listener.Close()
+	}
+	r.wait.Wait()
+
+	return err
+}
+
+// Host returns the host the server listens on.
+func (r *Server) Host() string {
+	return r.listener.Addr().(*net.TCPAddr).IP.String()
+}
+
+// Port returns the port the server listens on.
+func (r *Server) Port() int {
+	return r.listener.Addr().(*net.TCPAddr).Port
+}
+
+// Config returns the configuration of a connection to the server, to build a driver with sqlserver.NewStandaloneSqlserver.
+func (r *Server) Config() contracts.Config {
+	username := r.username
+	if username == "" {
+		username = defaultUsername
+	}
+
+	return contracts.Config{
+		Host:     r.Host(),
+		Port:     r.Port(),
+		Database: defaultDatabase,
+		Username: username,
+		Password: r.password,
+	}
+}
+
+// DSN returns a go-mssqldb connection string of the server.
+func (r *Server) DSN() string {
+	config := r.Config()
+	query := url.Values{}
+	query.Set("database", config.Database)
+	if r.tlsConfig != nil {
+		query.Set("TrustServerCertificate", "true")
+	}
+
+	dsn := url.URL{
+		Scheme:   "sqlserver",
+		User:     url.UserPassword(config.Username, config.Password),
+		Host:     net.JoinHostPort(config.Host, strconv.Itoa(config.Port)),
+		RawQuery: query.Encode(),
+	}
+
+	return dsn.String()
+}
+
+// ExpectLogin scripts the result of the next logins, WillReturnError rejects them (40613 for an unavailable
+// database, for example), WillDisconnect drops the connections and WillReturnMessages sends messages.
+func (r *Server) ExpectLogin() *Expectation {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	expectation := &Expectation{pattern: regexp.MustCompile("")}
+	r.loginExpectations = append(r.loginExpectations, expectation)
+
+	return expectation
+}
+
+// Logins returns the logins received by the server.
+func (r *Server) Logins() []Login {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return append([]Login(nil), r.logins...)
+}
+
+// Reset removes the statements, the logins and the expectations.
+func (r *Server) Reset() {
+	r.recorder.Reset()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.loginExpectations = nil
+	r.logins = nil
+}
+
+func (r *Server) accept() {
+	defer r.wait.Done()
+
+	for {
+		conn, err := r.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		r.lock.Lock()
+		if r.closed {
+			r.lock.Unlock()
+			_ = conn.Close()
+			return
+		}
+		r.conns[conn] = struct{}{}
+		r.wait.Add(1)
+		r.lock.Unlock()
+
+		go func() {
+			defer r.wait.Done()
+			defer func() {
+				r.lock.Lock()
+				delete(r.conns, conn)
+				r.lock.Unlock()
+				_ = conn.Close()
+			}()
+
+			_ = (&session{conn: conn, server: r, transport: conn}).serve()
+		}()
+	}
+}
+
+func (r *Server) recordLogin(login Login) *Expectation {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.logins = append(r.logins, login)
+	for _, expectation := range r.loginExpectations {
+		if expectation.match(login.Username) {
+			return expectation
+		}
+	}
+
+	return nil
+}
+
+// session is a client connection of Server.
+type session struct {
+	conn        net.Conn
+	server      *Server
+	transaction uint64
+	// transport is the TLS connection when the client requires the encryption.
+	transport io.ReadWriter
+}
+
+func (r *session) serve() error {
+	if err := r.login(); err != nil {
+		return err
+	}
+
+	for {
+		packetType, message, err := readMessage(r.transport)
+		if err != nil {
+			return err
+		}
+
+		var (
+			reply     tokens
+			statement Statement
+		)
+		switch packetType {
+		case packetSQLBatch:
+			statement.SQL, err = parseBatch(message)
+		case packetRPC:
+			statement, err = parseRPC(message)
+		case packetTransaction:
+			statement.SQL, err = r.parseTransaction(message)
+		case packetAttention:
+			reply.done(doneAttention, 0)
+			if err := writeMessage(r.transport, packetReply, reply.Bytes()); err != nil {
+				return err
+			}
+			continue
+		default:
+			err = fmt.Errorf("unsupported TDS packet type %d", packetType)
+		}
+		if err != nil {
+			return err
+		}
+
+		expectation := r.server.record(statement)
+		if expectation != nil && expectation.disconnect {
+			return errDisconnected
+		}
+		r.reply(&reply, statement.SQL, expectation)
+		if err := writeMessage(r.transport, packetReply, reply.Bytes()); err != nil {
+			return err
+		}
+	}
+}
+
+// login negotiates the encryption with PRELOGIN and answers LOGIN7. When the client turns the encryption off,
+// only LOGIN7 is encrypted, like SQL Server does, the client must disable it to skip TLS.
+func (r *session) login() error {
+	packetType, message, err := readMessage(r.conn)
+	if err != nil {
+		return err
+	}
+	if packetType != packetPrelogin {
+		return fmt.Errorf("expected PRELOGIN, got TDS packet type %d", packetType)
+	}
+	clientEncryption, err := preloginEncryptionOf(message)
+	if err != nil {
+		return err
+	}
+
+	encryption := byte(encryptNotSup)
+	if r.server.tlsConfig != nil && clientEncryption != encryptNotSup {
+		encryption = encryptOn
+		if clientEncryption == encryptOff {
+			encryption = encryptOff
+		}
+	}
+	if err := writeMessage(r.conn, packetReply, preloginResponse(encryption)); err != nil {
+		return err
+	}
+
+	if encryption != encryptNotSup {
+		passthrough := &passthroughConn{Conn: &handshakeConn{Conn: r.conn}}
+		tlsConn := tls.Server(passthrough, r.server.tlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			return err
+		}
+		passthrough.Conn = r.conn
+		r.transport = tlsConn
+	}
+
+	packetType, message, err = readMessage(r.transport)
+	if err != nil {
+		return err
+	}
+	if encryption == encryptOff {
+		r.transport = r.conn
+	}
+	if packetType != packetLogin {
+		return fmt.Errorf("expected LOGIN7, got TDS packet type %d", packetType)
+	}
+
+	login, err := parseLogin(message)
+	if err != nil {
+		return err
+	}
+	login.Encrypted = encryption != encryptNotSup
+
+	var reply tokens
+	expectation := r.server.recordLogin(login)
+	if expectation != nil {
+		if expectation.disconnect {
+			return errDisconnected
+		}
+		for _, message := range expectation.messages {
+			reply.message(tokenInfo, 0, 1, 0, message)
+		}
+	}
+
+	switch {
+	case expectation != nil && expectation.err != nil:
+		reply.error(expectation.err)
+	case r.server.username != "" && (login.Username != r.server.username || login.Password != r.server.password):
+		reply.message(tokenError, loginFailedErrorNumber, 1, 14, fmt.Sprintf("Login failed for user '%s'.", login.Username))
+		reply.done(doneError, 0)
+	default:
+		database := login.Database
+		if database == "" {
+			database = defaultDatabase
+		}
+		reply.envChange(envDatabase, database, defaultDatabase)
+		reply.loginAck()
+		reply.done(0, 0)
+
+		return writeMessage(r.transport, packetReply, reply.Bytes())
+	}
+
+	if err := writeMessage(r.transport, packetReply, reply.Bytes()); err != nil {
+		return err
+	}
+
+	return errors.New("login failed")
+}
+
+func (r *session) parseTransaction(message []byte) (string, error) {
+	request, err := parseTransaction(message)
+	if err != nil {
+		return "", err
+	}
+
+	switch request {
+	case transactionBegin:
+		return StatementBegin, nil
+	case transactionCommit:
+		return StatementCommit, nil
+	case transactionRollback:
+		return StatementRollback, nil
+	default:
+		return "", fmt.Errorf("unsupported transaction request %d", request)
+	}
+}
+
+func (r *session) reply(reply *tokens, statement string, expectation *Expectation) {
+	if expectation != nil {
+		for _, message := range expectation.messages {
+			reply.message(tokenInfo, 0, 1, 0, message)
+		}
+		if expectation.err != nil {
+			reply.error(expectation.err)
+			return
+		}
+	}
+
+	switch statement {
+	case StatementBegin:
+		r.transaction++
+		reply.envChangeBytes(envBeginTransaction, binary.LittleEndian.AppendUint64(nil, r.transaction), nil)
+	case StatementCommit:
+		reply.envChangeBytes(envCommitTransaction, nil, binary.LittleEndian.AppendUint64(nil, r.transaction))
+	case StatementRollback:
+		reply.envChangeBytes(envRollbackTransaction, nil, binary.LittleEndian.AppendUint64(nil, r.transaction))
+	}
+
+	switch {
+	case expectation == nil:
+		reply.done(0, 0)
+	case expectation.columns != nil:
+		rows := make([][]any, len(expectation.rows))
+		for i, row := range expectation.rows {
+			rows[i] = make([]any, len(row))
+			for j, value := range row {
+				if converted, err := driver.DefaultParameterConverter.ConvertValue(value); err == nil {
+					value = converted
+				}
+				rows[i][j] = value
+			}
+		}
+		reply.rows(expectation.columns, rows)
+		reply.done(doneCount, uint64(len(rows)))
+	default:
+		reply.done(doneCount, uint64(expectation.rowsAffected))
+	}
+}
+
+// error writes the ERROR token of an error, the number, the state and the class of a mssql.Error are kept.
+func (r *tokens) error(err error) {
+	sqlErr := mssql.Error{Number: userErrorNumber, State: 1, Class: 16, Message: err.Error()}
+	errors.As(err, &sqlErr)
+
+	r.message(tokenError, sqlErr.Number, sqlErr.State, sqlErr.Class, sqlErr.Message)
+	r.done(doneError, 0)
+}
+
+// handshakeConn wraps the TLS handshake in TDS packets, like go-mssqldb does on the client side.
+type handshakeConn struct {
+	net.Conn
+	pending []byte
+}
+
+func (r *handshakeConn) Read(p []byte) (int, error) {
+	if len(r.pending) == 0 {
+		_, message, err := readMessage(r.Conn)
+		if err != nil {
+			return 0, err
+		}
+		r.pending = message
+	}
+
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+
+	return n, nil
+}
+
+// Write sends a flight of the handshake as a message, crypto/tls writes a flight at once.
+func (r *handshakeConn) Write(p []byte) (int, error) {
+	if err := writeMessage(r.Conn, packetReply, p); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// passthroughConn switches the TLS connection from the handshake to the raw socket.
+type passthroughConn struct {
+	net.Conn
+}
+
+func selfSignedCertificate() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	return tls.Certificate{Certificate: [][]byte{certificate}, PrivateKey: key}, nil
+}
@@ -0,0 +1,200 @@
+package sqlservertest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/microsoft/go-mssqldb/msdsn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/goravel/sqlserver"
+	"github.com/goravel/sqlserver/contracts"
+)
+
+func startServer(t *testing.T, server *Server) *Server {
+	require.NoError(t, server.Start())
+	t.Cleanup(func() {
+		assert.NoError(t, server.Close())
+	})
+
+	return server
+}
+
+func TestServer(t *testing.T) {
+	server := startServer(t, NewServer().WithLogin("goravel", "Framework!123"))
+
+	config := server.Config()
+	config.Database = "goravel"
+	driver := sqlserver.NewStandaloneSqlserver(contracts.FullConfig{Config: config}, nil)
+	db, err := gorm.Open(driver.Pool().Writers[0].Dialector, &gorm.Config{Logger: logger.Discard, SkipDefaultTransaction: true})
+	require.NoError(t, err)
+
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 600, time.UTC)
+	server.Expect(`FROM "users"`).WillReturnRows([]string{"id", "name", "active", "score", "avatar", "created_at"},
+		[]any{1, "goravel", true, 1.5, []byte{1, 2}, createdAt},
+		[]any{2, nil, false, nil, nil, nil},
+	)
+	var users []map[string]any
+	assert.NoError(t, db.Table("users").Where("name <> ? and created_at < ?", "admin", createdAt).Find(&users).Error)
+	assert.Equal(t, []map[string]any{
+		{"id": int64(1), "name": "goravel", "active": true, "score": 1.5, "avatar": []byte{1, 2}, "created_at": createdAt},
+		{"id": int64(2), "name": nil, "active": false, "score": nil, "avatar": nil, "created_at": nil},
+	}, users)
+
+	server.Expect(`^UPDATE`).WillReturnResult(0, 2).Once()
+	result := db.Table("users").Where("id > ?", 0).Update("name", "goravel")
+	assert.NoError(t, result.Error)
+	assert.Equal(t, int64(2), result.RowsAffected)
+
+	server.Expect(`^INSERT`).WillReturnError(2627, "Violation of UNIQUE KEY constraint")
+	err = db.Exec("INSERT INTO users (name) VALUES (?)", "goravel").Error
+	var sqlErr mssql.Error
+	require.True(t, errors.As(err, &sqlErr))
+	assert.Equal(t, int32(2627), sqlErr.Number)
+	assert.Equal(t, "Violation of UNIQUE KEY constraint", sqlErr.Message)
+
+	assert.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+		return tx.Exec("DELETE FROM users").Error
+	}))
+
+	// gorm pings the connection when it's opened
+	statements := server.Statements()[1:]
+	require.Len(t, statements, 6)
+	assert.Equal(t, Statement{SQL: `SELECT * FROM "users" WHERE name <> @p1 and created_at < @p2`, Args: []any{"admin", createdAt}}, statements[0])
+	assert.Equal(t, Statement{SQL: `UPDATE "users" SET "name"=@p1 WHERE id > @p2`, Args: []any{"goravel", int64(0)}}, statements[1])
+	assert.Equal(t, Statement{SQL: "INSERT INTO users (name) VALUES (@p1)", Args: []any{"goravel"}}, statements[2])
+	assert.Equal(t, []string{StatementBegin, "DELETE FROM users", StatementCommit}, server.SQL()[4:])
+
+	logins := server.Logins()
+	require.NotEmpty(t, logins)
+	assert.Equal(t, "goravel", logins[0].Username)
+	assert.Equal(t, "Framework!123", logins[0].Password)
+	assert.Equal(t, "goravel", logins[0].Database)
+	assert.False(t, logins[0].Encrypted)
+}
+
+func TestServerLogin(t *testing.T) {
+	server := startServer(t, NewServer().WithLogin("goravel", "Framework!123"))
+
+	db := openServer(t, server.DSN())
+	assert.NoError(t, db.Ping())
+
+	dsn, err := url.Parse(server.DSN())
+	require.NoError(t, err)
+	dsn.User = url.UserPassword("goravel", "wrong")
+	err = openServer(t, dsn.String()).Ping()
+	var sqlErr mssql.Error
+	require.True(t, errors.As(err, &sqlErr))
+	assert.Equal(t, int32(18456), sqlErr.Number)
+	assert.Contains(t, err.Error(), "Login failed for user 'goravel'.")
+
+	server.Reset()
+	server.ExpectLogin().WillReturnError(40613, "Database 'goravel' is not currently available.").Once()
+	transient := openServer(t, server.DSN())
+	err = transient.Ping()
+	require.True(t, errors.As(err, &sqlErr))
+	assert.Equal(t, int32(40613), sqlErr.Number)
+	assert.NoError(t, transient.Ping())
+	assert.Len(t, server.Logins(), 2)
+}
+
+func TestServerDisconnect(t *testing.T) {
+	server := startServer(t, NewServer())
+	db := openServer(t, server.DSN())
+
+	server.Expect(`^DELETE`).WillDisconnect().Once()
+	_, err := db.Exec("DELETE FROM users")
+	assert.Error(t, err)
+
+	_, err = db.Exec("DELETE FROM users")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"DELETE FROM users", "DELETE FROM users"}, server.SQL())
+	assert.Len(t, server.Logins(), 2)
+
+	server.ExpectLogin().WillDisconnect().Once()
+	assert.Error(t, openServer(t, server.DSN()).Ping())
+}
+
+func TestServerTLS(t *testing.T) {
+	server := startServer(t, NewServer().WithTLS(nil))
+
+	for _, encrypt := range []string{"false", "true"} {
+		db := openServer(t, server.DSN()+"&encrypt="+encrypt)
+		server.Expect(`^select`).WillReturnRows([]string{"name"}, []any{"goravel"}).Once()
+
+		var name string
+		assert.NoError(t, db.QueryRow("select name from users where id = @id", sql.Named("id", 1)).Scan(&name))
+		assert.Equal(t, "goravel", name)
+	}
+
+	assert.Equal(t, Statement{SQL: "select name from users where id = @id", Args: []any{sql.Named("id", int64(1))}}, server.Statements()[1])
+	logins := server.Logins()
+	require.Len(t, logins, 2)
+	assert.True(t, logins[0].Encrypted)
+	assert.True(t, logins[1].Encrypted)
+
+	plain := startServer(t, NewServer())
+	assert.ErrorContains(t, openServer(t, plain.DSN()+"&encrypt=true").Ping(), "server does not support encryption")
+}
+
+func TestServerMessages(t *testing.T) {
+	server := startServer(t, NewServer())
+
+	messages := &messageLogger{}
+	connector, err := mssql.NewConnector(server.DSN() + "&log=2")
+	require.NoError(t, err)
+	mssql.SetContextLogger(messages)
+	defer mssql.SetContextLogger(nil)
+
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	server.Expect(`^exec`).WillReturnMessages("Changed database context to 'goravel'.", "done").WillReturnResult(0, 1)
+	_, err = db.Exec("exec sp_refresh")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Changed database context to 'goravel'.", "done"}, messages.messages())
+}
+
+func openServer(t *testing.T, dsn string) *sql.DB {
+	connector, err := mssql.NewConnector(dsn)
+	require.NoError(t, err)
+
+	db := sql.OpenDB(connector)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	return db
+}
+
+type messageLogger struct {
+	lock    sync.Mutex
+	entries []string
+}
+
+func (r *messageLogger) Log(_ context.Context, category msdsn.Log, message string) {
+	if category != msdsn.LogMessages {
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.entries = append(r.entries, message)
+}
+
+func (r *messageLogger) messages() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return append([]string(nil), r.entries...)
+}
@@ -0,0 +1,769 @@
+package sqlservertest
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// The subset of the TDS protocol spoken by Server, see https://learn.microsoft.com/openspecs/windows_protocols/ms-tds.
+const (
+	packetSQLBatch    = 1
+	packetRPC         = 3
+	packetReply       = 4
+	packetAttention   = 6
+	packetTransaction = 14
+	packetLogin       = 16
+	packetPrelogin    = 18
+
+	packetHeaderSize = 8
+	packetSize       = 4096
+	statusEOM        = 0x01
+
+	preloginVersion    = 0
+	preloginEncryption = 1
+	preloginInstance   = 2
+	preloginMARS       = 4
+	preloginTerminator = 0xff
+
+	encryptOff    = 0
+	encryptOn     = 1
+	encryptNotSup = 2
+
+	tokenColMetadata = 0x81
+	tokenError       = 0xaa
+	tokenInfo        = 0xab
+	tokenLoginAck    = 0xad
+	tokenRow         = 0xd1
+	tokenEnvChange   = 0xe3
+	tokenDone        = 0xfd
+
+	doneError     = 0x02
+	doneCount     = 0x10
+	doneAttention = 0x20
+
+	envDatabase            = 1
+	envBeginTransaction    = 8
+	envCommitTransaction   = 9
+	envRollbackTransaction = 10
+
+	transactionBegin    = 5
+	transactionCommit   = 7
+	transactionRollback = 8
+
+	procExecuteSQL = 10
+
+	typeNull            = 0x1f
+	typeInt1            = 0x30
+	typeBit             = 0x32
+	typeInt2            = 0x34
+	typeInt4            = 0x38
+	typeDateTime4       = 0x3a
+	typeFlt4            = 0x3b
+	typeMoney           = 0x3c
+	typeDateTime        = 0x3d
+	typeFlt8            = 0x3e
+	typeMoney4          = 0x7a
+	typeInt8            = 0x7f
+	typeGUID            = 0x24
+	typeIntN            = 0x26
+	typeDateN           = 0x28
+	typeTimeN           = 0x29
+	typeDateTime2N      = 0x2a
+	typeDateTimeOffsetN = 0x2b
+	typeBitN            = 0x68
+	typeDecimalN        = 0x6a
+	typeNumericN        = 0x6c
+	typeFltN            = 0x6d
+	typeMoneyN          = 0x6e
+	typeDateTimeN       = 0x6f
+	typeBigVarBin       = 0xa5
+	typeBigVarChar      = 0xa7
+	typeBigBinary       = 0xad
+	typeBigChar         = 0xaf
+	typeNVarChar        = 0xe7
+	typeNChar           = 0xef
+
+	plpNull       = math.MaxUint64
+	shortLenNull  = math.MaxUint16
+	tdsVersion74  = 0x74000004
+	daysTo1970    = 719162
+	serverName    = "sqlservertest"
+	serverProgram = "Microsoft SQL Server"
+)
+
+var (
+	// collation is Latin1_General_CI_AS, it's sent with the string columns.
+	collation = []byte{0x09, 0x04, 0xd0, 0x00, 0x34}
+	// ordinalRegexp matches the names go-mssqldb gives to the positional parameters.
+	ordinalRegexp = regexp.MustCompile(`^@p\d+$`)
+)
+
+// readMessage reads the packets of a message until the end of message status.
+func readMessage(reader io.Reader) (byte, []byte, error) {
+	var (
+		header     [packetHeaderSize]byte
+		message    []byte
+		packetType byte
+	)
+
+	for first := true; ; first = false {
+		if _, err := io.ReadFull(reader, header[:]); err != nil {
+			return 0, nil, err
+		}
+		size := int(binary.BigEndian.Uint16(header[2:4]))
+		if size < packetHeaderSize {
+			return 0, nil, fmt.Errorf("invalid TDS packet size %d", size)
+		}
+		if first {
+			packetType = header[0]
+		}
+
+		payload := make([]byte, size-packetHeaderSize)
+		if _, err := io.ReadFull(reader, payload); err != nil {
+			return 0, nil, err
+		}
+		message = append(message, payload...)
+
+		if header[1]&statusEOM != 0 {
+			return packetType, message, nil
+		}
+	}
+}
+
+// writeMessage splits a message into the packets of the default packet size, go-mssqldb rejects the bigger ones.
+func writeMessage(writer io.Writer, packetType byte, message []byte) error {
+	for id := byte(1); ; id++ {
+		size := min(len(message), packetSize-packetHeaderSize)
+		var status byte
+		if size == len(message) {
+			status = statusEOM
+		}
+
+		packet := make([]byte, packetHeaderSize, packetHeaderSize+size)
+		packet[0] = packetType
+		packet[1] = status
+		binary.BigEndian.PutUint16(packet[2:4], uint16(packetHeaderSize+size))
+		packet[6] = id
+		packet = append(packet, message[:size]...)
+		if _, err := writer.Write(packet); err != nil {
+			return err
+		}
+
+		message = message[size:]
+		if status == statusEOM {
+			return nil
+		}
+	}
+}
+
+// preloginEncryptionOf returns the encryption option of a PRELOGIN message.
+func preloginEncryptionOf(message []byte) (byte, error) {
+	for offset := 0; offset+5 <= len(message) && message[offset] != preloginTerminator; offset += 5 {
+		if message[offset] != preloginEncryption {
+			continue
+		}
+		start := int(binary.BigEndian.Uint16(message[offset+1:]))
+		if start >= len(message) {
+			break
+		}
+
+		return message[start], nil
+	}
+
+	return 0, errors.New("the ENCRYPTION option is missing in PRELOGIN")
+}
+
+func preloginResponse(encryption byte) []byte {
+	options := []struct {
+		token byte
+		data  []byte
+	}{
+		{token: preloginVersion, data: []byte{16, 0, 0x03, 0xe8, 0, 0}},
+		{token: preloginEncryption, data: []byte{encryption}},
+		{token: preloginInstance, data: []byte{0}},
+		{token: preloginMARS, data: []byte{0}},
+	}
+
+	var header, data []byte
+	offset := len(options)*5 + 1
+	for _, option := range options {
+		header = append(header, option.token)
+		header = binary.BigEndian.AppendUint16(header, uint16(offset+len(data)))
+		header = binary.BigEndian.AppendUint16(header, uint16(len(option.data)))
+		data = append(data, option.data...)
+	}
+
+	return append(append(header, preloginTerminator), data...)
+}
+
+// parseLogin parses a LOGIN7 message, the offsets and the lengths of its strings are in characters.
+func parseLogin(message []byte) (Login, error) {
+	if len(message) < 94 {
+		return Login{}, errors.New("the LOGIN7 message is too short")
+	}
+
+	field := func(at int) string {
+		offset := int(binary.LittleEndian.Uint16(message[at:]))
+		length := int(binary.LittleEndian.Uint16(message[at+2:])) * 2
+		if offset+length > len(message) {
+			return ""
+		}
+
+		return decodeUCS2(message[offset : offset+length])
+	}
+
+	passwordOffset := int(binary.LittleEndian.Uint16(message[44:]))
+	passwordLength := int(binary.LittleEndian.Uint16(message[46:])) * 2
+	if passwordOffset+passwordLength > len(message) {
+		return Login{}, errors.New("the password of LOGIN7 is out of range")
+	}
+	password := make([]byte, passwordLength)
+	for i, b := range message[passwordOffset : passwordOffset+passwordLength] {
+		b ^= 0xa5
+		password[i] = b<<4 | b>>4
+	}
+
+	return Login{
+		Username: field(40),
+		Password: decodeUCS2(password),
+		AppName:  field(48),
+		Database: field(68),
+		Hostname: field(36),
+		// fReadOnlyIntent of TypeFlags
+		ReadOnly: message[26]&0x20 != 0,
+	}, nil
+}
+
+// parseBatch returns the SQL of a SQL batch message.
+func parseBatch(message []byte) (string, error) {
+	reader := &messageReader{data: message}
+	reader.skipHeaders()
+	text := decodeUCS2(reader.rest())
+
+	return text, reader.err
+}
+
+// parseRPC returns the statement of a RPC message, the statement and the parameters of sp_executesql are
+// recorded, the name of the other procedures is recorded as the SQL.
+func parseRPC(message []byte) (Statement, error) {
+	reader := &messageReader{data: message}
+	reader.skipHeaders()
+
+	var name string
+	if id := reader.uint16(); id == 0xffff {
+		if procID := reader.uint16(); procID != procExecuteSQL {
+			name = fmt.Sprintf("sp_%d", procID)
+		}
+	} else {
+		name = decodeUCS2(reader.next(int(id) * 2))
+	}
+	reader.uint16()
+
+	var params []sql.NamedArg
+	for reader.err == nil && reader.remaining() > 0 {
+		paramName := reader.bVarChar()
+		reader.byte()
+		params = append(params, sql.Named(paramName, reader.value()))
+	}
+	if reader.err != nil {
+		return Statement{}, reader.err
+	}
+
+	if name != "" {
+		statement := Statement{SQL: name}
+		for _, param := range params {
+			statement.Args = append(statement.Args, paramValue(param))
+		}
+
+		return statement, nil
+	}
+
+	// sp_executesql @stmt, @params, @p1, @p2...
+	if len(params) == 0 {
+		return Statement{}, errors.New("sp_executesql without the statement")
+	}
+	statement := Statement{SQL: fmt.Sprint(params[0].Value)}
+	for i := 2; i < len(params); i++ {
+		statement.Args = append(statement.Args, paramValue(params[i]))
+	}
+
+	return statement, nil
+}
+
+// paramValue returns the value of the positional parameters and a sql.NamedArg of the named ones.
+func paramValue(param sql.NamedArg) any {
+	if param.Name == "" || ordinalRegexp.MatchString(param.Name) {
+		return param.Value
+	}
+
+	return sql.Named(strings.TrimPrefix(param.Name, "@"), param.Value)
+}
+
+// parseTransaction returns the request type of a transaction manager message.
+func parseTransaction(message []byte) (uint16, error) {
+	reader := &messageReader{data: message}
+	reader.skipHeaders()
+	request := reader.uint16()
+
+	return request, reader.err
+}
+
+// messageReader reads a client message, the first error is kept and the following reads return zero values.
+type messageReader struct {
+	data   []byte
+	err    error
+	offset int
+}
+
+func (r *messageReader) next(n int) []byte {
+	if r.err != nil {
+		return make([]byte, n)
+	}
+	if n < 0 || r.offset+n > len(r.data) {
+		r.err = io.ErrUnexpectedEOF
+		return make([]byte, max(n, 0))
+	}
+
+	data := r.data[r.offset : r.offset+n]
+	r.offset += n
+
+	return data
+}
+
+func (r *messageReader) remaining() int {
+	return len(r.data) - r.offset
+}
+
+func (r *messageReader) rest() []byte {
+	return r.next(r.remaining())
+}
+
+func (r *messageReader) byte() byte {
+	return r.next(1)[0]
+}
+
+func (r *messageReader) uint16() uint16 {
+	return binary.LittleEndian.Uint16(r.next(2))
+}
+
+func (r *messageReader) uint32() uint32 {
+	return binary.LittleEndian.Uint32(r.next(4))
+}
+
+func (r *messageReader) uint64() uint64 {
+	return binary.LittleEndian.Uint64(r.next(8))
+}
+
+func (r *messageReader) bVarChar() string {
+	return decodeUCS2(r.next(int(r.byte()) * 2))
+}
+
+// skipHeaders skips the ALL_HEADERS of a request, its length includes itself.
+func (r *messageReader) skipHeaders() {
+	r.next(int(r.uint32()) - 4)
+}
+
+// value reads the TYPE_INFO and the value of a RPC parameter. The integers, the floats, the bits, the strings, the
+// binaries and the dates are decoded, the values of the other types are returned as raw bytes.
+func (r *messageReader) value() any {
+	typeID := r.byte()
+
+	switch typeID {
+	case typeNull:
+		return nil
+	case typeInt1, typeBit:
+		return decodeValue(typeID, 0, r.next(1))
+	case typeInt2:
+		return decodeValue(typeID, 0, r.next(2))
+	case typeInt4, typeDateTime4, typeFlt4, typeMoney4:
+		return decodeValue(typeID, 0, r.next(4))
+	case typeMoney, typeDateTime, typeFlt8, typeInt8:
+		return decodeValue(typeID, 0, r.next(8))
+	case typeIntN, typeBitN, typeFltN, typeMoneyN, typeDateTimeN, typeGUID:
+		r.byte()
+		return r.byteLenValue(typeID, 0)
+	case typeDecimalN, typeNumericN:
+		r.next(3)
+		return r.byteLenValue(typeID, 0)
+	case typeDateN:
+		return r.byteLenValue(typeID, 0)
+	case typeTimeN, typeDateTime2N, typeDateTimeOffsetN:
+		return r.byteLenValue(typeID, r.byte())
+	case typeBigVarBin, typeBigBinary, typeBigVarChar, typeBigChar, typeNVarChar, typeNChar:
+		size := r.uint16()
+		if typeID == typeBigVarChar || typeID == typeBigChar || typeID == typeNVarChar || typeID == typeNChar {
+			r.next(len(collation))
+		}
+		if size == shortLenNull {
+			return r.plpValue(typeID)
+		}
+		length := r.uint16()
+		if length == shortLenNull {
+			return nil
+		}
+
+		return decodeValue(typeID, 0, r.next(int(length)))
+	default:
+		if r.err == nil {
+			r.err = fmt.Errorf("unsupported parameter type 0x%x", typeID)
+		}
+
+		return nil
+	}
+}
+
+func (r *messageReader) byteLenValue(typeID, scale byte) any {
+	length := r.byte()
+	if length == 0 {
+		return nil
+	}
+
+	return decodeValue(typeID, scale, r.next(int(length)))
+}
+
+func (r *messageReader) plpValue(typeID byte) any {
+	if r.uint64() == plpNull {
+		return nil
+	}
+
+	var data []byte
+	for r.err == nil {
+		chunk := r.uint32()
+		if chunk == 0 {
+			break
+		}
+		data = append(data, r.next(int(chunk))...)
+	}
+
+	return decodeValue(typeID, 0, data)
+}
+
+func decodeValue(typeID, scale byte, data []byte) any {
+	switch typeID {
+	case typeInt1:
+		return int64(data[0])
+	case typeInt2:
+		return int64(int16(binary.LittleEndian.Uint16(data)))
+	case typeInt4:
+		return int64(int32(binary.LittleEndian.Uint32(data)))
+	case typeInt8:
+		return int64(binary.LittleEndian.Uint64(data))
+	case typeIntN:
+		switch len(data) {
+		case 1:
+			return decodeValue(typeInt1, 0, data)
+		case 2:
+			return decodeValue(typeInt2, 0, data)
+		case 4:
+			return decodeValue(typeInt4, 0, data)
+		case 8:
+			return decodeValue(typeInt8, 0, data)
+		}
+	case typeBit, typeBitN:
+		return data[0] != 0
+	case typeFlt4:
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(data)))
+	case typeFlt8:
+		return math.Float64frombits(binary.LittleEndian.Uint64(data))
+	case typeFltN:
+		if len(data) == 4 {
+			return decodeValue(typeFlt4, 0, data)
+		}
+		return decodeValue(typeFlt8, 0, data)
+	case typeNVarChar, typeNChar:
+		return decodeUCS2(data)
+	case typeBigVarChar, typeBigChar:
+		return string(data)
+	case typeDateTime2N:
+		return decodeDateTime2(scale, data, time.UTC)
+	case typeDateTimeOffsetN:
+		if len(data) < 2 {
+			break
+		}
+		value := decodeDateTime2(scale, data[:len(data)-2], time.UTC)
+		if offset := int(int16(binary.LittleEndian.Uint16(data[len(data)-2:]))); offset != 0 {
+			value = value.In(time.FixedZone("", offset*60))
+		}
+		return value
+	}
+
+	return append([]byte(nil), data...)
+}
+
+// decodeDateTime2 decodes the time of day in 10^-scale seconds followed by the days since 0001-01-01.
+func decodeDateTime2(scale byte, data []byte, location *time.Location) time.Time {
+	if len(data) < 3 {
+		return time.Time{}
+	}
+
+	var ticks uint64
+	for i, b := range data[:len(data)-3] {
+		ticks |= uint64(b) << (8 * i)
+	}
+	for range 7 - int(scale) {
+		ticks *= 10
+	}
+	date := data[len(data)-3:]
+	days := int64(date[0]) | int64(date[1])<<8 | int64(date[2])<<16
+
+	return time.Unix((days-daysTo1970)*86400, int64(ticks)*100).In(location)
+}
+
+func decodeUCS2(data []byte) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(data[i*2:])
+	}
+
+	return string(utf16.Decode(units))
+}
+
+func encodeUCS2(value string) []byte {
+	units := utf16.Encode([]rune(value))
+	data := make([]byte, 0, len(units)*2)
+	for _, unit := range units {
+		data = binary.LittleEndian.AppendUint16(data, unit)
+	}
+
+	return data
+}
+
+// tokens builds the token stream of a reply.
+type tokens struct {
+	bytes.Buffer
+}
+
+func (r *tokens) uint16(value uint16) {
+	r.Write(binary.LittleEndian.AppendUint16(nil, value))
+}
+
+func (r *tokens) uint32(value uint32) {
+	r.Write(binary.LittleEndian.AppendUint32(nil, value))
+}
+
+func (r *tokens) uint64(value uint64) {
+	r.Write(binary.LittleEndian.AppendUint64(nil, value))
+}
+
+func (r *tokens) bVarChar(value string) {
+	data := encodeUCS2(value)
+	r.WriteByte(byte(len(data) / 2))
+	r.Write(data)
+}
+
+func (r *tokens) usVarChar(value string) {
+	data := encodeUCS2(value)
+	r.uint16(uint16(len(data) / 2))
+	r.Write(data)
+}
+
+// sized writes a token whose length precedes its data.
+func (r *tokens) sized(token byte, fill func(data *tokens)) {
+	var data tokens
+	fill(&data)
+
+	r.WriteByte(token)
+	r.uint16(uint16(data.Len()))
+	r.Write(data.Bytes())
+}
+
+func (r *tokens) loginAck() {
+	r.sized(tokenLoginAck, func(data *tokens) {
+		data.WriteByte(1)
+		data.Write(binary.BigEndian.AppendUint32(nil, tdsVersion74))
+		data.bVarChar(serverProgram)
+		data.Write([]byte{16, 0, 0x03, 0xe8})
+	})
+}
+
+func (r *tokens) envChange(envType byte, newValue, oldValue string) {
+	r.sized(tokenEnvChange, func(data *tokens) {
+		data.WriteByte(envType)
+		data.bVarChar(newValue)
+		data.bVarChar(oldValue)
+	})
+}
+
+func (r *tokens) envChangeBytes(envType byte, newValue, oldValue []byte) {
+	r.sized(tokenEnvChange, func(data *tokens) {
+		data.WriteByte(envType)
+		data.WriteByte(byte(len(newValue)))
+		data.Write(newValue)
+		data.WriteByte(byte(len(oldValue)))
+		data.Write(oldValue)
+	})
+}
+
+// message writes an ERROR or an INFO token.
+func (r *tokens) message(token byte, number int32, state, class byte, message string) {
+	r.sized(token, func(data *tokens) {
+		data.uint32(uint32(number))
+		data.WriteByte(state)
+		data.WriteByte(class)
+		data.usVarChar(message)
+		data.bVarChar(serverName)
+		data.bVarChar("")
+		data.uint32(1)
+	})
+}
+
+func (r *tokens) done(status uint16, count uint64) {
+	r.WriteByte(tokenDone)
+	r.uint16(status)
+	r.uint16(0)
+	r.uint64(count)
+}
+
+// rows writes a result set, the type of a column is chosen by its values: bigint, float, bit, varbinary(max),
+// datetime2 or nvarchar(max) when the values are strings or have different types.
+func (r *tokens) rows(columns []string, rows [][]any) {
+	types := make([]byte, len(columns))
+	for i := range columns {
+		types[i] = columnType(rows, i)
+	}
+
+	r.WriteByte(tokenColMetadata)
+	r.uint16(uint16(len(columns)))
+	for i, column := range columns {
+		r.uint32(0)
+		// nullable
+		r.uint16(1)
+		r.WriteByte(types[i])
+		switch types[i] {
+		case typeIntN, typeFltN:
+			r.WriteByte(8)
+		case typeBitN:
+			r.WriteByte(1)
+		case typeDateTime2N:
+			r.WriteByte(7)
+		case typeBigVarBin:
+			r.uint16(shortLenNull)
+		default:
+			r.uint16(shortLenNull)
+			r.Write(collation)
+		}
+		r.bVarChar(column)
+	}
+
+	for _, row := range rows {
+		r.WriteByte(tokenRow)
+		for i := range columns {
+			var value any
+			if i < len(row) {
+				value = row[i]
+			}
+			r.value(types[i], value)
+		}
+	}
+}
+
+func (r *tokens) value(typeID byte, value any) {
+	switch typeID {
+	case typeIntN, typeFltN, typeBitN, typeDateTime2N:
+		if value == nil {
+			r.WriteByte(0)
+			return
+		}
+	default:
+		if value == nil {
+			r.uint64(plpNull)
+			return
+		}
+	}
+
+	switch typeID {
+	case typeIntN:
+		r.WriteByte(8)
+		r.uint64(uint64(value.(int64)))
+	case typeFltN:
+		r.WriteByte(8)
+		r.uint64(math.Float64bits(value.(float64)))
+	case typeBitN:
+		r.WriteByte(1)
+		if value.(bool) {
+			r.WriteByte(1)
+		} else {
+			r.WriteByte(0)
+		}
+	case typeDateTime2N:
+		r.WriteByte(8)
+		r.Write(encodeDateTime2(value.(time.Time)))
+	default:
+		var data []byte
+		if typeID == typeBigVarBin {
+			data = value.([]byte)
+		} else if text, ok := value.(string); ok {
+			data = encodeUCS2(text)
+		} else {
+			data = encodeUCS2(fmt.Sprint(value))
+		}
+
+		r.uint64(uint64(len(data)))
+		if len(data) > 0 {
+			r.uint32(uint32(len(data)))
+			r.Write(data)
+		}
+		r.uint32(0)
+	}
+}
+
+func columnType(rows [][]any, column int) byte {
+	var typeID byte
+	for _, row := range rows {
+		if column >= len(row) || row[column] == nil {
+			continue
+		}
+
+		var current byte
+		switch row[column].(type) {
+		case int64:
+			current = typeIntN
+		case float64:
+			current = typeFltN
+		case bool:
+			current = typeBitN
+		case []byte:
+			current = typeBigVarBin
+		case time.Time:
+			current = typeDateTime2N
+		default:
+			return typeNVarChar
+		}
+		if typeID != 0 && typeID != current {
+			return typeNVarChar
+		}
+		typeID = current
+	}
+
+	if typeID == 0 {
+		return typeNVarChar
+	}
+
+	return typeID
+}
+
+// encodeDateTime2 encodes the wall clock of a time as datetime2(7).
+func encodeDateTime2(value time.Time) []byte {
+	year, month, day := value.Date()
+	days := time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix()/86400 + daysTo1970
+	ticks := uint64(value.Hour()*3600+value.Minute()*60+value.Second())*10_000_000 + uint64(value.Nanosecond()/100)
+
+	data := make([]byte, 8)
+	for i := range 5 {
+		data[i] = byte(ticks >> (8 * i))
+	}
+	for i := range 3 {
+		data[5+i] = byte(days >> (8 * i))
+	}
+
+	return data
+}
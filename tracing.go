@@ -0,0 +1,366 @@
+package sqlserver
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"unicode"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/goravel/sqlserver/contracts"
+)
+
+const (
+	RoleReader = "reader"
+	RoleWriter = "writer"
+
+	tracerName = "github.com/goravel/sqlserver"
+)
+
+var (
+	AttributeConnection   = attribute.Key("db.sqlserver.connection")
+	AttributeRole         = attribute.Key("db.sqlserver.role")
+	AttributeRowsAffected = attribute.Key("db.sqlserver.rows_affected")
+	AttributeErrorNumber  = attribute.Key("db.sqlserver.error_number")
+)
+
+var (
+	_ driver.Connector          = &tracedConnector{}
+	_ driver.Conn               = &tracedConn{}
+	_ driver.ConnBeginTx        = &tracedConn{}
+	_ driver.ConnPrepareContext = &tracedConn{}
+	_ driver.NamedValueChecker  = &tracedConn{}
+	_ driver.Pinger             = &tracedConn{}
+	_ driver.SessionResetter    = &tracedConn{}
+	_ driver.Validator          = &tracedConn{}
+	_ driver.StmtExecContext    = &tracedStmt{}
+	_ driver.StmtQueryContext   = &tracedStmt{}
+)
+
+// tracedConnector emits an OpenTelemetry span for every connection, statement and transaction.
+type tracedConnector struct {
+	connector  driver.Connector
+	tracer     trace.Tracer
+	attributes []attribute.KeyValue
+}
+
+func newTracedConnector(connector driver.Connector, fullConfig contracts.FullConfig, role string) *tracedConnector {
+	tracerProvider := fullConfig.TracerProvider
+	if tracerProvider == nil {
+		tracerProvider = otel.GetTracerProvider()
+	}
+
+	return &tracedConnector{
+		connector: connector,
+		tracer:    tracerProvider.Tracer(tracerName),
+		attributes: []attribute.KeyValue{
+			semconv.DBSystemMSSQL,
+			semconv.DBName(fullConfig.Database),
+			semconv.DBUser(fullConfig.Username),
+			semconv.ServerAddress(fullConfig.Host),
+			semconv.ServerPort(fullConfig.Port),
+			AttributeConnection.String(fullConfig.Connection),
+			AttributeRole.String(role),
+		},
+	}
+}
+
+func (r *tracedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	ctx, span := r.start(ctx, "sqlserver.connect")
+	conn, err := r.connector.Connect(ctx)
+	r.end(span, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tracedConn{conn: conn, connector: r}, nil
+}
+
+func (r *tracedConnector) Driver() driver.Driver {
+	return r.connector.Driver()
+}
+
+func (r *tracedConnector) start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return r.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(r.attributes...),
+		trace.WithAttributes(attributes...),
+	)
+}
+
+func (r *tracedConnector) end(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, driver.ErrSkip) {
+		var sqlErr interface{ SQLErrorNumber() int32 }
+		if errors.As(err, &sqlErr) {
+			span.SetAttributes(AttributeErrorNumber.Int64(int64(sqlErr.SQLErrorNumber())))
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+type tracedConn struct {
+	conn      driver.Conn
+	connector *tracedConnector
+}
+
+func (r *tracedConn) Begin() (driver.Tx, error) {
+	return r.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (r *tracedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	ctx, span := r.connector.start(ctx, "sqlserver.begin")
+
+	var (
+		tx  driver.Tx
+		err error
+	)
+	if beginTx, ok := r.conn.(driver.ConnBeginTx); ok {
+		tx, err = beginTx.BeginTx(ctx, opts)
+	} else {
+		tx, err = r.conn.Begin() //nolint:staticcheck
+	}
+	r.connector.end(span, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tracedTx{tx: tx, ctx: ctx, connector: r.connector}, nil
+}
+
+func (r *tracedConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := r.conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+
+	return driver.ErrSkip
+}
+
+func (r *tracedConn) Close() error {
+	return r.conn.Close()
+}
+
+func (r *tracedConn) IsValid() bool {
+	if validator, ok := r.conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+
+	return true
+}
+
+func (r *tracedConn) Ping(ctx context.Context) error {
+	if pinger, ok := r.conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+
+	return nil
+}
+
+func (r *tracedConn) Prepare(query string) (driver.Stmt, error) {
+	return r.PrepareContext(context.Background(), query)
+}
+
+func (r *tracedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var (
+		stmt driver.Stmt
+		err  error
+	)
+	if prepare, ok := r.conn.(driver.ConnPrepareContext); ok {
+		stmt, err = prepare.PrepareContext(ctx, query)
+	} else {
+		stmt, err = r.conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &tracedStmt{stmt: stmt, query: query, connector: r.connector}, nil
+}
+
+func (r *tracedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := r.conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+
+	return nil
+}
+
+// Unwrap returns the underlying go-mssqldb connection, it can be used in sql.Conn.Raw.
+func (r *tracedConn) Unwrap() driver.Conn {
+	return r.conn
+}
+
+type tracedStmt struct {
+	stmt      driver.Stmt
+	connector *tracedConnector
+	query     string
+}
+
+func (r *tracedStmt) Close() error {
+	return r.stmt.Close()
+}
+
+func (r *tracedStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return r.ExecContext(context.Background(), valuesToNamedValues(args))
+}
+
+func (r *tracedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	ctx, span := r.connector.start(ctx, "sqlserver.exec", r.statementAttributes()...)
+
+	var (
+		result driver.Result
+		err    error
+	)
+	if exec, ok := r.stmt.(driver.StmtExecContext); ok {
+		result, err = exec.ExecContext(ctx, args)
+	} else {
+		result, err = r.stmt.Exec(namedValuesToValues(args)) //nolint:staticcheck
+	}
+	if err == nil {
+		if rowsAffected, err := result.RowsAffected(); err == nil {
+			span.SetAttributes(AttributeRowsAffected.Int64(rowsAffected))
+		}
+	}
+	r.connector.end(span, err)
+
+	return result, err
+}
+
+func (r *tracedStmt) NumInput() int {
+	return r.stmt.NumInput()
+}
+
+func (r *tracedStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return r.QueryContext(context.Background(), valuesToNamedValues(args))
+}
+
+func (r *tracedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	ctx, span := r.connector.start(ctx, "sqlserver.query", r.statementAttributes()...)
+
+	var (
+		rows driver.Rows
+		err  error
+	)
+	if query, ok := r.stmt.(driver.StmtQueryContext); ok {
+		rows, err = query.QueryContext(ctx, args)
+	} else {
+		rows, err = r.stmt.Query(namedValuesToValues(args)) //nolint:staticcheck
+	}
+	r.connector.end(span, err)
+
+	return rows, err
+}
+
+func (r *tracedStmt) statementAttributes() []attribute.KeyValue {
+	statement := SanitizeStatement(r.query)
+	attributes := []attribute.KeyValue{semconv.DBStatement(statement)}
+	if fields := strings.Fields(statement); len(fields) > 0 {
+		attributes = append(attributes, semconv.DBOperation(strings.ToUpper(fields[0])))
+	}
+
+	return attributes
+}
+
+type tracedTx struct {
+	tx        driver.Tx
+	ctx       context.Context
+	connector *tracedConnector
+}
+
+func (r *tracedTx) Commit() error {
+	_, span := r.connector.start(r.ctx, "sqlserver.commit")
+	err := r.tx.Commit()
+	r.connector.end(span, err)
+
+	return err
+}
+
+func (r *tracedTx) Rollback() error {
+	_, span := r.connector.start(r.ctx, "sqlserver.rollback")
+	err := r.tx.Rollback()
+	r.connector.end(span, err)
+
+	return err
+}
+
+// SanitizeStatement replaces the string and numeric literals of a statement with "?",
+// parameters (@p1), identifiers and comments are kept as they are.
+func SanitizeStatement(statement string) string {
+	var (
+		builder strings.Builder
+		runes   = []rune(statement)
+	)
+
+	builder.Grow(len(statement))
+	for i := 0; i < len(runes); i++ {
+		current := runes[i]
+		switch {
+		case current == '\'' || ((current == 'N' || current == 'n') && i+1 < len(runes) && runes[i+1] == '\'' && !isIdentifierRune(runes, i-1)):
+			if current != '\'' {
+				i++
+			}
+			for i++; i < len(runes); i++ {
+				if runes[i] == '\'' {
+					if i+1 < len(runes) && runes[i+1] == '\'' {
+						i++
+						continue
+					}
+					break
+				}
+			}
+			builder.WriteRune('?')
+		case current == '[' || current == '"':
+			closing := ']'
+			if current == '"' {
+				closing = '"'
+			}
+			start := i
+			for i++; i < len(runes) && runes[i] != closing; i++ {
+			}
+			builder.WriteString(string(runes[start:min(i+1, len(runes))]))
+		case unicode.IsDigit(current) && !isIdentifierRune(runes, i-1):
+			for i+1 < len(runes) && (unicode.IsDigit(runes[i+1]) || runes[i+1] == '.') {
+				i++
+			}
+			builder.WriteRune('?')
+		default:
+			builder.WriteRune(current)
+		}
+	}
+
+	return builder.String()
+}
+
+func isIdentifierRune(runes []rune, i int) bool {
+	if i < 0 || i >= len(runes) {
+		return false
+	}
+
+	return unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '@' || runes[i] == '#' || runes[i] == '$'
+}
+
+func namedValuesToValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+
+	return values
+}
+
+func valuesToNamedValues(args []driver.Value) []driver.NamedValue {
+	values := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		values[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+
+	return values
+}
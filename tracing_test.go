@@ -0,0 +1,209 @@
+package sqlserver
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"testing"
+
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/goravel/sqlserver/contracts"
+)
+
+type TracingTestSuite struct {
+	suite.Suite
+	db       *sql.DB
+	exporter *tracetest.InMemoryExporter
+}
+
+func TestTracingTestSuite(t *testing.T) {
+	suite.Run(t, &TracingTestSuite{})
+}
+
+func (s *TracingTestSuite) SetupTest() {
+	s.exporter = tracetest.NewInMemoryExporter()
+	connector := newTracedConnector(&stubConnector{}, contracts.FullConfig{
+		Config: contracts.Config{
+			Host:     "127.0.0.1",
+			Port:     1433,
+			Database: "goravel",
+			Username: "sa",
+		},
+		Connection:     "sqlserver",
+		Tracing:        true,
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSyncer(s.exporter)),
+	}, RoleWriter)
+	s.db = sql.OpenDB(connector)
+}
+
+func (s *TracingTestSuite) TearDownTest() {
+	s.NoError(s.db.Close())
+}
+
+func (s *TracingTestSuite) TestExec() {
+	_, err := s.db.Exec("update users set name = 'goravel' where id = @p1", 1)
+	s.NoError(err)
+
+	spans := s.exporter.GetSpans()
+	s.Len(spans, 2)
+	s.Equal("sqlserver.connect", spans[0].Name)
+	s.Equal("sqlserver.exec", spans[1].Name)
+
+	attributes := attributesToMap(spans[1].Attributes)
+	s.Equal("mssql", attributes["db.system"])
+	s.Equal("update users set name = ? where id = @p1", attributes["db.statement"])
+	s.Equal("UPDATE", attributes["db.operation"])
+	s.Equal("goravel", attributes["db.name"])
+	s.Equal("sqlserver", attributes[string(AttributeConnection)])
+	s.Equal(RoleWriter, attributes[string(AttributeRole)])
+	s.Equal(int64(3), attributes[string(AttributeRowsAffected)])
+}
+
+func (s *TracingTestSuite) TestQueryError() {
+	_, err := s.db.Query("select * from missing")
+	s.Error(err)
+
+	spans := s.exporter.GetSpans()
+	s.Len(spans, 2)
+	s.Equal("sqlserver.query", spans[1].Name)
+	s.Equal(codes.Error, spans[1].Status.Code)
+	s.Equal(int64(208), attributesToMap(spans[1].Attributes)[string(AttributeErrorNumber)])
+}
+
+func (s *TracingTestSuite) TestTransaction() {
+	tx, err := s.db.Begin()
+	s.NoError(err)
+	_, err = tx.Exec("delete from users")
+	s.NoError(err)
+	s.NoError(tx.Commit())
+
+	var names []string
+	for _, span := range s.exporter.GetSpans() {
+		names = append(names, span.Name)
+	}
+	s.Equal([]string{"sqlserver.connect", "sqlserver.begin", "sqlserver.exec", "sqlserver.commit"}, names)
+}
+
+func TestSanitizeStatement(t *testing.T) {
+	tests := []struct {
+		statement string
+		expect    string
+	}{
+		{
+			statement: "select * from users where id = @p1",
+			expect:    "select * from users where id = @p1",
+		},
+		{
+			statement: "select * from users where name = N'it''s' and age > 18 and score = 1.5",
+			expect:    "select * from users where name = ? and age > ? and score = ?",
+		},
+		{
+			statement: `select [column 1], "name2" from users2 where [id's] = 'a'`,
+			expect:    `select [column 1], "name2" from users2 where [id's] = ?`,
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expect, SanitizeStatement(test.statement))
+	}
+}
+
+func attributesToMap(attributes []attribute.KeyValue) map[string]any {
+	values := make(map[string]any)
+	for _, attr := range attributes {
+		values[string(attr.Key)] = attr.Value.AsInterface()
+	}
+
+	return values
+}
+
+type stubConnector struct {
+	queries []string
+}
+
+func (r *stubConnector) Connect(_ context.Context) (driver.Conn, error) {
+	return &stubConn{connector: r}, nil
+}
+
+func (r *stubConnector) Driver() driver.Driver {
+	return nil
+}
+
+type stubConn struct {
+	connector *stubConnector
+}
+
+func (r *stubConn) Begin() (driver.Tx, error) {
+	return &stubTx{}, nil
+}
+
+func (r *stubConn) CheckNamedValue(_ *driver.NamedValue) error {
+	return nil
+}
+
+func (r *stubConn) Close() error {
+	return nil
+}
+
+func (r *stubConn) Prepare(query string) (driver.Stmt, error) {
+	r.connector.queries = append(r.connector.queries, query)
+
+	return &stubStmt{query: query}, nil
+}
+
+type stubStmt struct {
+	query string
+}
+
+func (r *stubStmt) Close() error {
+	return nil
+}
+
+func (r *stubStmt) Exec(_ []driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(3), nil
+}
+
+func (r *stubStmt) NumInput() int {
+	return -1
+}
+
+func (r *stubStmt) Query(_ []driver.Value) (driver.Rows, error) {
+	if strings.Contains(r.query, "missing") {
+		return nil, mssql.Error{Number: 208, Message: "Invalid object name 'missing'."}
+	}
+
+	return &stubRows{}, nil
+}
+
+type stubRows struct{}
+
+func (r *stubRows) Close() error {
+	return nil
+}
+
+func (r *stubRows) Columns() []string {
+	return nil
+}
+
+func (r *stubRows) Next(_ []driver.Value) error {
+	return io.EOF
+}
+
+type stubTx struct{}
+
+func (r *stubTx) Commit() error {
+	return nil
+}
+
+func (r *stubTx) Rollback() error {
+	return nil
+}
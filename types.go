@@ -0,0 +1,34 @@
+package sqlserver
+
+import (
+	"fmt"
+)
+
+// NewSequentialID is the default of a uniqueidentifier column generating sequential values, they keep the inserts
+// at the end of a clustered index. It can only be used as a default:
+//
+//	table.Uuid("id").Default(schema.Expression(sqlserver.NewSequentialID))
+const NewSequentialID = "newsequentialid()"
+
+// Binary returns the type of a fixed-length binary column, from 1 to 8000 bytes. Use it as the type of a column:
+//
+//	table.Column("hash", sqlserver.Binary(32))
+func Binary(length int) string {
+	return fmt.Sprintf("binary(%d)", length)
+}
+
+// VarBinary returns the type of a variable-length binary column, from 1 to 8000 bytes, or varbinary(max) if the
+// length is 0.
+func VarBinary(length int) string {
+	if length <= 0 {
+		return "varbinary(max)"
+	}
+
+	return fmt.Sprintf("varbinary(%d)", length)
+}
+
+// DateTime2 returns the type of a datetime2 column with the fractional seconds precision, from 0 to 7. DateTime
+// and Timestamp create a datetime2 only when their precision is greater than 0.
+func DateTime2(precision int) string {
+	return fmt.Sprintf("datetime2(%d)", precision)
+}